@@ -5,13 +5,80 @@ import (
 	"github.com/web-page-analysis/util"
 )
 
+type LinkClassificationConfig struct {
+	// links to subdomains of the page host are counted as internal
+	SubdomainsInternal bool `yaml:"subdomains_internal"`
+	// www.example.com and example.com are treated as the same host
+	IgnoreWWW bool `yaml:"ignore_www"`
+}
+
+type RobotsConfig struct {
+	// fetch the robots.txt of every checked host and report the disallowed links
+	Enabled bool `yaml:"enabled"`
+	// the robots.txt group matching this user agent is applied
+	UserAgent string `yaml:"user_agent"`
+	// skip the disallowed links when checking and crawling, and wait the crawl-delay between crawled pages
+	Respect bool `yaml:"respect"`
+	// robots.txt files are cached per host for this many milliseconds
+	CacheTTL int64 `yaml:"cache_ttl"`
+	// upper bound of the crawl-delay honoured, in milliseconds
+	MaxCrawlDelay int64 `yaml:"max_crawl_delay"`
+}
+
+type LinkCacheConfig struct {
+	// reuse the result of a link checked by an earlier analysis
+	Enabled bool `yaml:"enabled"`
+	// results are kept for this many milliseconds
+	TTL int64 `yaml:"ttl"`
+	// the least recently used results are dropped above this number
+	MaxEntries int64 `yaml:"max_entries"`
+}
+
+// ThresholdConfig makes the command line analysis exit with 1 when a limit is exceeded,
+// a negative limit is not checked
+type ThresholdConfig struct {
+	MaxBrokenLinks         int64 `yaml:"max_broken_links"`
+	RequireTitle           bool  `yaml:"require_title"`
+	MaxAccessibilityErrors int64 `yaml:"max_accessibility_errors"`
+}
+
 type AppConfig struct {
 	Port        int64 `yaml:"port"`
 	WorkerCount int64 `yaml:"worker_count"`
+	// crawl limits, requests asking for more are capped to these values
+	CrawlMaxDepth int64 `yaml:"crawl_max_depth"`
+	CrawlMaxPages int64 `yaml:"crawl_max_pages"`
+	// number of analysis jobs running at the same time
+	JobConcurrency int64 `yaml:"job_concurrency"`
+	// finished jobs are kept for this many milliseconds
+	JobRetention int64 `yaml:"job_retention"`
+	// jobs waiting for a slot, new jobs are refused above it
+	JobMaxQueued int64 `yaml:"job_max_queued"`
+	// results of paged analyses kept to read their next pages from, the oldest is dropped above it
+	JobMaxStored int64 `yaml:"job_max_stored"`
+
+	// milliseconds an analysis may take, requests asking for more are capped to it,
+	// what is finished by then is returned as a truncated result
+	AnalysisTimeout int64 `yaml:"analysis_timeout"`
+	// bytes of html accepted by /analyse/html
+	MaxHTMLSize int64 `yaml:"max_html_size"`
+	// urls accepted in a batch and the pages of a batch analysed at the same time
+	BatchMaxUrls     int64 `yaml:"batch_max_urls"`
+	BatchConcurrency int64 `yaml:"batch_concurrency"`
+	// bytes of url list accepted by /batch
+	MaxBatchSize int64 `yaml:"max_batch_size"`
+
+	// urls of a sitemap checked at most, requests asking for more are capped to it
+	SitemapMaxChecks int64 `yaml:"sitemap_max_checks"`
+
+	LinkClassification LinkClassificationConfig `yaml:"link_classification"`
+	Robots             RobotsConfig             `yaml:"robots"`
+	LinkCache          LinkCacheConfig          `yaml:"link_cache"`
+	Thresholds         ThresholdConfig          `yaml:"thresholds"`
 }
 
-func initAppConfig() error {
-	err := util.YamlReader(`bootstrap/config/app.yaml`, &AppConf)
+func initAppConfig(path string) error {
+	err := util.YamlReader(path, &AppConf)
 	if err != nil {
 		log.Errorf("init app config error: %v", err)
 		return err
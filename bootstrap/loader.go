@@ -1,28 +1,52 @@
 package bootstrap
 
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ConfigDirEnv names the directory the yaml files are read from,
+	// the server and the commands can then be started from anywhere
+	ConfigDirEnv     = "WEB_PAGE_ANALYSIS_CONFIG_DIR"
+	defaultConfigDir = "bootstrap/config"
+)
+
 var (
 	AppConf      AppConfig
 	OutboundConf OutboundConfig
+	RulesConf    RulesConfig
 )
 
 type Config struct {
 	AppConfig    AppConfig
 	OutboundConf OutboundConfig
+	RulesConf    RulesConfig
 }
 
 func InitConfig() (conf Config, err error) {
-	err = initAppConfig()
+	dir := os.Getenv(ConfigDirEnv)
+	if dir == "" {
+		dir = defaultConfigDir
+	}
+	err = initAppConfig(filepath.Join(dir, "app.yaml"))
+	if err != nil {
+		return conf, err
+	}
+
+	err = initOutboundConfig(filepath.Join(dir, "outbound.yaml"))
 	if err != nil {
 		return conf, err
 	}
 
-	err = initOutboundConfig()
+	err = initRulesConfig(filepath.Join(dir, "rules.yaml"))
 	if err != nil {
 		return conf, err
 	}
 	conf = Config{
 		AppConfig:    AppConf,
 		OutboundConf: OutboundConf,
+		RulesConf:    RulesConf,
 	}
 	return conf, nil
 }
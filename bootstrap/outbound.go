@@ -5,13 +5,61 @@ import (
 	"github.com/web-page-analysis/util"
 )
 
+// HostLimitConfig keeps the checks polite to every single host,
+// whatever the number of workers
+type HostLimitConfig struct {
+	// requests in flight to the same host, unlimited when 0
+	MaxConcurrency int64 `yaml:"max_concurrency"`
+	// milliseconds between the start of two requests to the same host
+	MinDelay int64 `yaml:"min_delay"`
+	// a 429 answer pauses the host for its Retry-After, up to this many milliseconds
+	MaxRetryAfter int64 `yaml:"max_retry_after"`
+	// times a request answered with 429 is sent again after the pause
+	RateLimitRetries int64 `yaml:"rate_limit_retries"`
+}
+
+// RetryConfig sends a request again when it failed in a way which may not last
+type RetryConfig struct {
+	// attempts in total, the first one included, 1 or less does not retry
+	MaxAttempts int64 `yaml:"max_attempts"`
+	// milliseconds before the first retry, doubled for each next one up to MaxBackoff
+	BaseBackoff int64 `yaml:"base_backoff"`
+	MaxBackoff  int64 `yaml:"max_backoff"`
+	// fraction of the backoff added or taken off at random, 0.2 is up to 20%
+	Jitter float64 `yaml:"jitter"`
+	// status codes answered by a server which is only busy for now
+	RetryableStatuses []int `yaml:"retryable_statuses"`
+	// errors retried, any of timeout, connection_refused, connection and dns
+	RetryableErrors []string `yaml:"retryable_errors"`
+}
+
+// LinkCheckConfig learns the status of a link without downloading it
+type LinkCheckConfig struct {
+	// ask with HEAD first, a GET is sent when HEAD is not supported
+	HeadFirst bool `yaml:"head_first"`
+	// statuses answered to HEAD which are asked again with GET
+	FallbackStatuses []int `yaml:"fallback_statuses"`
+	// bytes of a GET body read at most, the rest is never downloaded
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+}
+
 type OutboundConfig struct {
 	DialTimeout   int64 `yaml:"dial_timeout"`
 	RemoteTimeout int64 `yaml:"remote_timeout"`
+	// redirects followed before giving up, loops are stopped right away
+	MaxRedirects int64 `yaml:"max_redirects"`
+	// reject connections to loopback, link-local, private and multicast addresses
+	BlockPrivateNetworks bool `yaml:"block_private_networks"`
+	// cidrs, ips or host names reachable even when they resolve to a blocked address
+	AllowedNetworks []string `yaml:"allowed_networks"`
+
+	HostLimits HostLimitConfig `yaml:"host_limits"`
+	Retry      RetryConfig     `yaml:"retry"`
+	LinkCheck  LinkCheckConfig `yaml:"link_check"`
 }
 
-func initOutboundConfig() error {
-	err := util.YamlReader(`bootstrap/config/outbound.yaml`, &OutboundConf)
+func initOutboundConfig(path string) error {
+	err := util.YamlReader(path, &OutboundConf)
 	if err != nil {
 		log.Errorf("init app config error: %v", err)
 		return err
@@ -0,0 +1,139 @@
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/util"
+	"regexp"
+)
+
+// CountAssertion checks the number of elements the selector matches
+type CountAssertion struct {
+	Equals *int `yaml:"equals"`
+	Min    *int `yaml:"min"`
+	Max    *int `yaml:"max"`
+}
+
+// AttributeAssertion is checked on every matched element
+type AttributeAssertion struct {
+	Name        string `yaml:"name"`
+	Exists      *bool  `yaml:"exists"`
+	Equals      string `yaml:"equals"`
+	Contains    string `yaml:"contains"`
+	NotContains string `yaml:"not_contains"`
+	Matches     string `yaml:"matches"`
+	// MatchesPattern is Matches compiled when the config is loaded
+	MatchesPattern *regexp.Regexp `yaml:"-"`
+}
+
+// TextAssertion is checked on the trimmed text of every matched element
+type TextAssertion struct {
+	NotEmpty    bool   `yaml:"not_empty"`
+	Equals      string `yaml:"equals"`
+	Contains    string `yaml:"contains"`
+	NotContains string `yaml:"not_contains"`
+	Matches     string `yaml:"matches"`
+	// MatchesPattern is Matches compiled when the config is loaded
+	MatchesPattern *regexp.Regexp `yaml:"-"`
+}
+
+type RuleConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Selector    string `yaml:"selector"`
+	// regular expression on the page url, the rule only runs on the matching pages
+	UrlPattern string `yaml:"url_pattern"`
+	// severity of the finding reported when the rule fails, warning by default
+	Severity  string              `yaml:"severity"`
+	Count     *CountAssertion     `yaml:"count"`
+	Attribute *AttributeAssertion `yaml:"attribute"`
+	Text      *TextAssertion      `yaml:"text"`
+
+	// the selector and the url pattern compiled when the config is loaded
+	CompiledSelector   cascadia.Selector `yaml:"-"`
+	CompiledUrlPattern *regexp.Regexp    `yaml:"-"`
+}
+
+type RulesConfig struct {
+	Rules []RuleConfig `yaml:"rules"`
+}
+
+func initRulesConfig(path string) error {
+	err := util.YamlReader(path, &RulesConf)
+	if err != nil {
+		log.Errorf("init rules config error: %v", err)
+		return err
+	}
+	err = RulesConf.Compile()
+	if err != nil {
+		log.Errorf("init rules config error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Compile validates the rules and compiles their selectors and patterns once,
+// a rule which cannot run stops the startup instead of failing on every page
+func (c *RulesConfig) Compile() error {
+	for i := range c.Rules {
+		if err := c.Rules[i].compile(); err != nil {
+			return fmt.Errorf("rule %d %s: %w", i+1, c.Rules[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *RuleConfig) compile() (err error) {
+	if r.Name == "" {
+		return errors.New("rule has no name")
+	}
+	if r.Count == nil && r.Attribute == nil && r.Text == nil {
+		return errors.New("rule has no count, attribute or text assertion")
+	}
+	switch r.Severity {
+	case "", domain.SeverityError, domain.SeverityWarning, domain.SeverityInfo:
+	default:
+		return fmt.Errorf("unknown severity %q", r.Severity)
+	}
+	r.CompiledSelector, err = cascadia.Compile(r.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector %q: %v", r.Selector, err)
+	}
+	if r.UrlPattern != "" {
+		r.CompiledUrlPattern, err = regexp.Compile(r.UrlPattern)
+		if err != nil {
+			return fmt.Errorf("invalid url_pattern %q: %v", r.UrlPattern, err)
+		}
+	}
+	if r.Attribute != nil {
+		if r.Attribute.Name == "" {
+			return errors.New("attribute assertion has no name")
+		}
+		r.Attribute.MatchesPattern, err = compilePattern(r.Attribute.Matches)
+		if err != nil {
+			return err
+		}
+	}
+	if r.Text != nil {
+		r.Text.MatchesPattern, err = compilePattern(r.Text.Matches)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compilePattern compiles the matches of an assertion, nil when none is given
+func compilePattern(matches string) (*regexp.Regexp, error) {
+	if matches == "" {
+		return nil, nil
+	}
+	pattern, err := regexp.Compile(matches)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", matches, err)
+	}
+	return pattern, nil
+}
@@ -0,0 +1,55 @@
+package bootstrap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRulesConfigCompile(t *testing.T) {
+	conf := RulesConfig{}
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+rules:
+  - name: single_price
+    url_pattern: "/products/"
+    selector: ".price"
+    count:
+      equals: 1
+  - name: priced_in_euro
+    selector: ".price"
+    text:
+      matches: "EUR$"
+`), &conf))
+	assert.NoError(t, conf.Compile())
+	assert.NotNil(t, conf.Rules[0].CompiledSelector)
+	assert.True(t, conf.Rules[0].CompiledUrlPattern.MatchString("https://abc.com/products/1"))
+	assert.True(t, conf.Rules[1].Text.MatchesPattern.MatchString("10 EUR"))
+}
+
+func TestRulesConfigCompileInvalid(t *testing.T) {
+	invalid := map[string]string{
+		"rule has no name": "- selector: p\n  count: {min: 1}",
+		"rule has no count, attribute or text assertion": "- name: no_assertion\n  selector: p",
+		`unknown severity "fatal"`:                       "- name: unknown_severity\n  selector: p\n  severity: fatal\n  count: {min: 1}",
+		`invalid selector "p[["`:                         "- name: broken_selector\n  selector: p[[\n  count: {min: 1}",
+		`invalid url_pattern "("`:                        "- name: broken_url\n  selector: p\n  url_pattern: \"(\"\n  count: {min: 1}",
+		"attribute assertion has no name":                "- name: no_attribute\n  selector: p\n  attribute: {exists: true}",
+		`invalid pattern "("`:                            "- name: broken_pattern\n  selector: p\n  text: {matches: \"(\"}",
+	}
+	for message, rules := range invalid {
+		conf := RulesConfig{}
+		assert.NoError(t, yaml.Unmarshal([]byte("rules:\n"+rules), &conf), message)
+		err := conf.Compile()
+		if assert.Error(t, err, message) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+}
+
+func TestInitRulesConfigFailsOnAnInvalidRule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: broken_selector\n    selector: \"p[[\"\n    count: {min: 1}\n"), 0o600))
+	assert.Error(t, initRulesConfig(path))
+}
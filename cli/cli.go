@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/server"
+	"github.com/web-page-analysis/service"
+	"github.com/web-page-analysis/usecase"
+	"io"
+	"strings"
+)
+
+const (
+	// ExitPassed is returned when the analysis ran and every threshold is met
+	ExitPassed = 0
+	// ExitFailed is returned when a threshold is exceeded
+	ExitFailed = 1
+	// ExitError is returned on a bad command line, when the analysis could not run
+	// or when it was truncated
+	ExitError = 2
+
+	formatText = "text"
+	formatJSON = "json"
+
+	usage = `usage: web-page-analysis <command> [flags]
+
+commands:
+  serve                  start the http server, the default without a command
+  analyse [flags] <url>  analyse a page and check it against the thresholds
+  crawl [flags] <url>    crawl a site and check every page against the thresholds
+
+run "web-page-analysis <command> -h" for the flags of a command
+`
+)
+
+// options are the flags shared by the analyse and crawl commands
+type options struct {
+	format      string
+	analysers   string
+	bypassCache bool
+	verbose     bool
+	thresholds  bootstrap.ThresholdConfig
+}
+
+// Run executes the command given in args and returns the exit code of the process
+func Run(ctx context.Context, args []string, conf bootstrap.Config, ctr container.Container,
+	stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "serve" {
+		<-server.InitRouter(ctx, conf, ctr)
+		return ExitPassed
+	}
+	switch args[0] {
+	case "analyse", "analyze":
+		return analyse(ctx, args[1:], conf, ctr, stdout, stderr)
+	case "crawl":
+		return crawl(ctx, args[1:], conf, ctr, stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return ExitPassed
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return ExitError
+	}
+}
+
+func analyse(ctx context.Context, args []string, conf bootstrap.Config, ctr container.Container,
+	stdout io.Writer, stderr io.Writer) int {
+	flags, opts := newFlagSet("analyse", conf, stderr)
+	timeout := flags.Int("timeout", 0, "milliseconds the analysis may take, capped by analysis_timeout")
+	pageURL, err := parseArgs(flags, opts, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+	if err = opts.checkAnalysers(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+
+	req := domain.AnalyserRequest{
+		Url:                pageURL,
+		IncludeLinkDetails: opts.format == formatJSON,
+		Analysers:          opts.analyserNames(),
+		BypassCache:        opts.bypassCache,
+		Timeout:            *timeout,
+	}
+	result, _, err := service.NewAnalyser(ctr, conf).WebAnalyser(ctx, req)
+	if err != nil {
+		fmt.Fprintf(stderr, "error in analysing %s: %v\n", pageURL, err)
+		return ExitError
+	}
+	checks := checkPage(result, opts.thresholds)
+	if err = writeReport(stdout, opts.format, pageReport(pageURL, result, checks)); err != nil {
+		fmt.Fprintf(stderr, "error in writing the report: %v\n", err)
+		return ExitError
+	}
+	if result.Truncated {
+		fmt.Fprintf(stderr, "the analysis of %s was truncated, the thresholds cannot be checked on a partial result\n", pageURL)
+	}
+	return exitCode(checks, result.Truncated)
+}
+
+func crawl(ctx context.Context, args []string, conf bootstrap.Config, ctr container.Container,
+	stdout io.Writer, stderr io.Writer) int {
+	flags, opts := newFlagSet("crawl", conf, stderr)
+	maxDepth := flags.Int("max-depth", 0, "link depth followed from the page, capped by crawl_max_depth")
+	maxPages := flags.Int("max-pages", 0, "pages analysed at most, capped by crawl_max_pages")
+	siteURL, err := parseArgs(flags, opts, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+	// a crawl always runs these for its summary
+	if err = opts.checkAnalysers(usecase.AnalyserLinks, usecase.AnalyserTitle); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+
+	req := domain.CrawlRequest{
+		Url:         siteURL,
+		MaxDepth:    *maxDepth,
+		MaxPages:    *maxPages,
+		Analysers:   opts.analyserNames(),
+		BypassCache: opts.bypassCache,
+	}
+	result, _, err := service.NewCrawler(ctr, conf).Crawl(ctx, req)
+	if err != nil {
+		fmt.Fprintf(stderr, "error in crawling %s: %v\n", siteURL, err)
+		return ExitError
+	}
+	checks := checkCrawl(result, opts.thresholds)
+	if err = writeReport(stdout, opts.format, crawlReport(siteURL, result, checks)); err != nil {
+		fmt.Fprintf(stderr, "error in writing the report: %v\n", err)
+		return ExitError
+	}
+	truncated := crawlTruncated(result)
+	if truncated {
+		fmt.Fprintf(stderr, "the crawl of %s was truncated, the thresholds cannot be checked on a partial result\n", siteURL)
+	}
+	return exitCode(checks, truncated)
+}
+
+// newFlagSet declares the shared flags, the thresholds default to the ones of app.yaml
+func newFlagSet(name string, conf bootstrap.Config, stderr io.Writer) (*flag.FlagSet, *options) {
+	opts := &options{thresholds: conf.AppConfig.Thresholds}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.format, "format", formatText, "report format, text or json")
+	flags.StringVar(&opts.analysers, "analysers", "", "comma separated analysers to run, all of them when empty")
+	flags.BoolVar(&opts.bypassCache, "bypass-cache", false, "check every link again instead of reusing cached results")
+	flags.BoolVar(&opts.verbose, "verbose", false, "write the logs to stderr")
+	flags.Int64Var(&opts.thresholds.MaxBrokenLinks, "max-broken-links", opts.thresholds.MaxBrokenLinks,
+		"broken links allowed, -1 to not check")
+	flags.BoolVar(&opts.thresholds.RequireTitle, "require-title", opts.thresholds.RequireTitle,
+		"fail when a page has no title")
+	flags.Int64Var(&opts.thresholds.MaxAccessibilityErrors, "max-accessibility-errors", opts.thresholds.MaxAccessibilityErrors,
+		"accessibility errors allowed, -1 to not check")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: web-page-analysis %s [flags] <url>\n", name)
+		flags.PrintDefaults()
+	}
+	return flags, opts
+}
+
+// parseArgs reads the flags and the single url, the flags may also follow the url,
+// the errors are written to the output of the flag set
+func parseArgs(flags *flag.FlagSet, opts *options, args []string) (string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return "", err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(flags.Output(), "exactly one url is expected")
+		flags.Usage()
+		return "", errors.New("exactly one url is expected")
+	}
+	if opts.format != formatText && opts.format != formatJSON {
+		err := fmt.Errorf("unknown format %q, expected text or json", opts.format)
+		fmt.Fprintln(flags.Output(), err)
+		return "", err
+	}
+	// the logs are kept out of the report unless asked for
+	if !opts.verbose {
+		log.SetOutput(io.Discard)
+	}
+	return positional[0], nil
+}
+
+// usageExitCode tells a bad command line apart from asking for the help
+func usageExitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitPassed
+	}
+	return ExitError
+}
+
+// checkAnalysers makes sure every threshold checked has its analyser selected,
+// a threshold on a section which did not run would pass or fail for nothing
+func (o *options) checkAnalysers(always ...string) error {
+	names := o.analyserNames()
+	if len(names) == 0 {
+		return nil
+	}
+	names = append(names, always...)
+	required := []struct {
+		checked  bool
+		flag     string
+		analyser string
+	}{
+		{o.thresholds.MaxBrokenLinks >= 0, "max-broken-links", usecase.AnalyserLinks},
+		{o.thresholds.RequireTitle, "require-title", usecase.AnalyserTitle},
+		{o.thresholds.MaxAccessibilityErrors >= 0, "max-accessibility-errors", usecase.AnalyserAccessibility},
+	}
+	for _, r := range required {
+		if r.checked && !contains(names, r.analyser) {
+			return fmt.Errorf("-%s needs the %s analyser, add it to -analysers or turn the threshold off",
+				r.flag, r.analyser)
+		}
+	}
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if strings.TrimSpace(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *options) analyserNames() []string {
+	if o.analysers == "" {
+		return nil
+	}
+	return strings.Split(o.analysers, ",")
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func runForTest(t *testing.T, args ...string) (code int, stdout string, stderr string) {
+	conf := bootstrap.Config{
+		AppConfig: bootstrap.AppConfig{
+			WorkerCount:     4,
+			CrawlMaxDepth:   2,
+			CrawlMaxPages:   10,
+			AnalysisTimeout: 5000,
+			Thresholds:      bootstrap.ThresholdConfig{MaxBrokenLinks: 0, RequireTitle: true, MaxAccessibilityErrors: -1},
+		},
+		OutboundConf: bootstrap.OutboundConfig{DialTimeout: 1000},
+	}
+	ctr := container.Container{OBAdapter: container.InitOutBoundConnection(conf)}
+	var out, errOut bytes.Buffer
+	code = Run(context.Background(), args, conf, ctr, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func siteForTest(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><title>Home</title></head><body><a href="/about">about</a></body></html>`)
+		case "/about":
+			fmt.Fprint(w, `<html><head><title>About</title></head></html>`)
+		case "/broken":
+			fmt.Fprint(w, `<html><head><title>Broken</title></head><body><a href="/missing">missing</a></body></html>`)
+		case "/untitled":
+			fmt.Fprint(w, `<html><body></body></html>`)
+		case "/slow":
+			fmt.Fprint(w, `<html><head><title>Slow</title></head><body><a href="/sleep">sleep</a></body></html>`)
+		case "/sleep":
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunAnalyseExitCodes(t *testing.T) {
+	server := siteForTest(t)
+
+	code, stdout, _ := runForTest(t, "analyse", server.URL)
+	assert.Equal(t, ExitPassed, code)
+	assert.Contains(t, stdout, "result  passed")
+
+	code, stdout, _ = runForTest(t, "analyse", server.URL+"/broken")
+	assert.Equal(t, ExitFailed, code)
+	assert.Contains(t, stdout, "FAIL  broken links")
+
+	// the flags may follow the url
+	code, _, _ = runForTest(t, "analyse", server.URL+"/untitled", "-require-title=false")
+	assert.Equal(t, ExitPassed, code)
+
+	code, _, stderr := runForTest(t, "analyse", "-timeout", "200", server.URL+"/slow")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "truncated")
+
+	code, _, stderr = runForTest(t, "analyse", "http://127.0.0.1:1/")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "error in analysing")
+}
+
+func TestRunThresholdsNeedTheirAnalysers(t *testing.T) {
+	server := siteForTest(t)
+
+	// require-title is on by default
+	code, _, stderr := runForTest(t, "analyse", "-analysers", "links", server.URL)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "-require-title needs the title analyser")
+
+	code, _, stderr = runForTest(t, "analyse", "-analysers", "title", server.URL)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "-max-broken-links needs the links analyser")
+
+	code, _, _ = runForTest(t, "analyse", "-analysers", "title", "-max-broken-links", "-1", server.URL)
+	assert.Equal(t, ExitPassed, code)
+
+	// a crawl always has the links and the title
+	code, _, _ = runForTest(t, "crawl", "-analysers", "seo", server.URL)
+	assert.Equal(t, ExitPassed, code)
+	code, _, stderr = runForTest(t, "crawl", "-analysers", "seo", "-max-accessibility-errors", "0", server.URL)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "-max-accessibility-errors needs the accessibility analyser")
+}
+
+func TestRunCommandLine(t *testing.T) {
+	code, stdout, _ := runForTest(t, "help")
+	assert.Equal(t, ExitPassed, code)
+	assert.Contains(t, stdout, "usage:")
+
+	code, _, stderr := runForTest(t, "inspect")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, `unknown command "inspect"`)
+
+	code, _, _ = runForTest(t, "analyse", "-h")
+	assert.Equal(t, ExitPassed, code)
+
+	code, _, stderr = runForTest(t, "analyse")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "exactly one url is expected")
+
+	code, _, stderr = runForTest(t, "crawl", "-format", "xml", "http://example.com")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "unknown format")
+}
+
+func TestParseArgs(t *testing.T) {
+	flags, opts := newFlagSet("analyse", bootstrap.Config{}, io.Discard)
+	url, err := parseArgs(flags, opts, []string{"-format", "json", "http://example.com", "-verbose", "-analysers", "seo,links"})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com", url)
+	assert.Equal(t, formatJSON, opts.format)
+	assert.True(t, opts.verbose)
+	assert.Equal(t, []string{"seo", "links"}, opts.analyserNames())
+
+	flags, opts = newFlagSet("analyse", bootstrap.Config{}, io.Discard)
+	_, err = parseArgs(flags, opts, []string{"http://a.com", "http://b.com"})
+	assert.Error(t, err)
+
+	flags, opts = newFlagSet("analyse", bootstrap.Config{}, io.Discard)
+	_, err = parseArgs(flags, opts, []string{"-h"})
+	assert.ErrorIs(t, err, flag.ErrHelp)
+	assert.Equal(t, ExitPassed, usageExitCode(err))
+}
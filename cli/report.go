@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// check is one threshold compared with what was found
+type check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// report is written as it is in json, the text form leaves out the raw results
+type report struct {
+	Url    string                 `json:"url"`
+	Passed bool                   `json:"passed"`
+	Checks []check                `json:"checks"`
+	Page   *domain.AnalysisResult `json:"page,omitempty"`
+	Crawl  *domain.CrawlResult    `json:"crawl,omitempty"`
+}
+
+func pageReport(url string, result domain.AnalysisResult, checks []check) report {
+	return report{Url: url, Passed: exitCode(checks, result.Truncated) == ExitPassed, Checks: checks, Page: &result}
+}
+
+func crawlReport(url string, result domain.CrawlResult, checks []check) report {
+	return report{Url: url, Passed: exitCode(checks, crawlTruncated(result)) == ExitPassed, Checks: checks, Crawl: &result}
+}
+
+// checkPage compares the analysis of a page with the thresholds, the negative ones are skipped
+func checkPage(result domain.AnalysisResult, thresholds bootstrap.ThresholdConfig) []check {
+	checks := make([]check, 0)
+	if thresholds.MaxBrokenLinks >= 0 {
+		checks = append(checks, limitCheck("broken links", result.Link.InaccessibleLinkCount, thresholds.MaxBrokenLinks))
+	}
+	if thresholds.RequireTitle {
+		titled := strings.TrimSpace(result.Title) != ""
+		checks = append(checks, check{Name: "title", Passed: titled, Detail: map[bool]string{
+			true: "present", false: "missing",
+		}[titled]})
+	}
+	if thresholds.MaxAccessibilityErrors >= 0 {
+		checks = append(checks, limitCheck("accessibility errors", result.Accessibility.ErrorCount,
+			thresholds.MaxAccessibilityErrors))
+	}
+	return checks
+}
+
+// checkCrawl compares the totals of the crawled pages with the thresholds, a page which
+// could not be fetched is a broken link of the page linking to it so it is not checked again
+func checkCrawl(result domain.CrawlResult, thresholds bootstrap.ThresholdConfig) []check {
+	checks := make([]check, 0)
+	if thresholds.MaxBrokenLinks >= 0 {
+		checks = append(checks, limitCheck("broken links", result.Summary.BrokenLinkCount, thresholds.MaxBrokenLinks))
+	}
+	if thresholds.RequireTitle {
+		missing := len(result.Summary.PagesWithoutTitle)
+		checks = append(checks, check{Name: "title", Passed: missing == 0,
+			Detail: fmt.Sprintf("%d of %d pages without a title", missing, result.Summary.TotalPages)})
+	}
+	if thresholds.MaxAccessibilityErrors >= 0 {
+		errorCount := 0
+		for _, page := range result.Pages {
+			if page.Result != nil {
+				errorCount += page.Result.Accessibility.ErrorCount
+			}
+		}
+		checks = append(checks, limitCheck("accessibility errors", errorCount, thresholds.MaxAccessibilityErrors))
+	}
+	return checks
+}
+
+func limitCheck(name string, found int, max int64) check {
+	return check{Name: name, Passed: int64(found) <= max, Detail: fmt.Sprintf("%d found, %d allowed", found, max)}
+}
+
+// exitCode is an error for a truncated result, the links left unchecked may be broken
+func exitCode(checks []check, truncated bool) int {
+	if truncated {
+		return ExitError
+	}
+	for _, c := range checks {
+		if !c.Passed {
+			return ExitFailed
+		}
+	}
+	return ExitPassed
+}
+
+// crawlTruncated tells whether the crawl or the analysis of one of its pages was cut short
+func crawlTruncated(result domain.CrawlResult) bool {
+	if result.Truncated {
+		return true
+	}
+	for _, page := range result.Pages {
+		if page.Result != nil && page.Result.Truncated {
+			return true
+		}
+	}
+	return false
+}
+
+func writeReport(w io.Writer, format string, r report) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "url\t%s\n", r.Url)
+	if r.Page != nil {
+		writePage(tw, *r.Page)
+	}
+	if r.Crawl != nil {
+		writeCrawl(tw, *r.Crawl)
+	}
+	fmt.Fprintln(tw)
+	for _, c := range r.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", map[bool]string{true: "PASS", false: "FAIL"}[c.Passed], c.Name, c.Detail)
+	}
+	fmt.Fprintf(tw, "\nresult\t%s\n", map[bool]string{true: "passed", false: "failed"}[r.Passed])
+	return tw.Flush()
+}
+
+func writePage(w io.Writer, result domain.AnalysisResult) {
+	fmt.Fprintf(w, "title\t%s\n", result.Title)
+	fmt.Fprintf(w, "html version\t%s\n", result.HTMLVersion)
+	fmt.Fprintf(w, "login form\t%t\n", result.HasLoginForm)
+	fmt.Fprintf(w, "links\t%d internal, %d external, %d inaccessible\n",
+		result.Link.InternalLinks, result.Link.ExternalLinks, result.Link.InaccessibleLinkCount)
+	for _, link := range result.Link.InaccessibleLink {
+		fmt.Fprintf(w, "\t  %s\n", link)
+	}
+	fmt.Fprintf(w, "accessibility\t%d errors, %d warnings\n",
+		result.Accessibility.ErrorCount, result.Accessibility.WarningCount)
+	if result.Truncated {
+		fmt.Fprintf(w, "truncated\tthe analysis ran out of time, the result is partial\n")
+	}
+}
+
+func writeCrawl(w io.Writer, result domain.CrawlResult) {
+	fmt.Fprintf(w, "pages\t%d analysed, %d failed\n", result.Summary.TotalPages, result.Summary.FailedPages)
+	fmt.Fprintf(w, "broken links\t%d\n", result.Summary.BrokenLinkCount)
+	for _, link := range result.Summary.BrokenLinks {
+		fmt.Fprintf(w, "\t  %s\n", link)
+	}
+	for _, page := range result.Summary.PagesWithoutTitle {
+		fmt.Fprintf(w, "no title\t%s\n", page)
+	}
+	for _, page := range result.Pages {
+		if page.Error != "" {
+			fmt.Fprintf(w, "failed\t%s: %s\n", page.Url, page.Error)
+		}
+	}
+	if result.Truncated {
+		fmt.Fprintf(w, "truncated\tthe crawl was stopped, the result is partial\n")
+	}
+}
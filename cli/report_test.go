@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"testing"
+)
+
+func TestCheckPage(t *testing.T) {
+	result := domain.AnalysisResult{
+		Title:         "",
+		Link:          domain.Link{InaccessibleLinkCount: 2},
+		Accessibility: domain.Accessibility{ErrorCount: 1},
+	}
+
+	checks := checkPage(result, bootstrap.ThresholdConfig{MaxBrokenLinks: 2, RequireTitle: true, MaxAccessibilityErrors: 0})
+	assert.Equal(t, []check{
+		{Name: "broken links", Passed: true, Detail: "2 found, 2 allowed"},
+		{Name: "title", Passed: false, Detail: "missing"},
+		{Name: "accessibility errors", Passed: false, Detail: "1 found, 0 allowed"},
+	}, checks)
+	assert.Equal(t, ExitFailed, exitCode(checks, false))
+
+	// the negative thresholds are not checked
+	checks = checkPage(result, bootstrap.ThresholdConfig{MaxBrokenLinks: -1, MaxAccessibilityErrors: -1})
+	assert.Empty(t, checks)
+	assert.Equal(t, ExitPassed, exitCode(checks, false))
+}
+
+func TestCheckCrawl(t *testing.T) {
+	result := domain.CrawlResult{
+		Pages: []domain.PageResult{
+			{Url: "http://example.com/", Result: &domain.AnalysisResult{Accessibility: domain.Accessibility{ErrorCount: 2}}},
+			{Url: "http://example.com/a", Result: &domain.AnalysisResult{Accessibility: domain.Accessibility{ErrorCount: 1}}},
+			{Url: "http://example.com/missing", Error: "not found"},
+		},
+		Summary: domain.CrawlSummary{
+			TotalPages:        3,
+			FailedPages:       1,
+			BrokenLinkCount:   1,
+			PagesWithoutTitle: []string{"http://example.com/a"},
+		},
+	}
+
+	checks := checkCrawl(result, bootstrap.ThresholdConfig{MaxBrokenLinks: 1, RequireTitle: true, MaxAccessibilityErrors: 3})
+	assert.Equal(t, []check{
+		{Name: "broken links", Passed: true, Detail: "1 found, 1 allowed"},
+		{Name: "title", Passed: false, Detail: "1 of 3 pages without a title"},
+		{Name: "accessibility errors", Passed: true, Detail: "3 found, 3 allowed"},
+	}, checks)
+	assert.Equal(t, ExitFailed, exitCode(checks, false))
+}
+
+func TestWriteReport(t *testing.T) {
+	checks := []check{{Name: "title", Passed: true, Detail: "present"}}
+	r := pageReport("http://example.com/", domain.AnalysisResult{Title: "Home"}, checks)
+
+	var text bytes.Buffer
+	assert.NoError(t, writeReport(&text, formatText, r))
+	assert.Contains(t, text.String(), "PASS  title")
+	assert.Contains(t, text.String(), "result  passed")
+
+	var raw bytes.Buffer
+	assert.NoError(t, writeReport(&raw, formatJSON, r))
+	assert.Contains(t, raw.String(), `"passed": true`)
+	assert.Contains(t, raw.String(), `"title": "Home"`)
+}
@@ -0,0 +1,110 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"net"
+	"strings"
+	"syscall"
+)
+
+var (
+	ErrDisallowedAddress = errors.New("destination address is not allowed")
+
+	// ranges which are not reachable from the public internet
+	// and are not covered by the net.IP helpers
+	restrictedNetworks = []string{
+		"0.0.0.0/8",
+		"100.64.0.0/10",
+		"192.0.0.0/24",
+		"198.18.0.0/15",
+	}
+)
+
+// addressGuard rejects outbound connections to internal addresses.
+// The check runs on the resolved ip of every connection, so it also
+// covers redirects and hostnames re-bound to an internal address
+type addressGuard struct {
+	enabled    bool
+	allowed    []*net.IPNet
+	allowHosts map[string]interface{}
+	restricted []*net.IPNet
+}
+
+func newAddressGuard(conf bootstrap.OutboundConfig) addressGuard {
+	guard := addressGuard{
+		enabled:    conf.BlockPrivateNetworks,
+		allowHosts: make(map[string]interface{}),
+	}
+	for _, entry := range conf.AllowedNetworks {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			guard.allowed = append(guard.allowed, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			guard.allowed = append(guard.allowed, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		guard.allowHosts[entry] = nil
+	}
+	for _, cidr := range restrictedNetworks {
+		_, network, _ := net.ParseCIDR(cidr)
+		guard.restricted = append(guard.restricted, network)
+	}
+	return guard
+}
+
+func (g addressGuard) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if !g.enabled {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := g.allowHosts[strings.ToLower(host)]; ok {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		guarded := *dialer
+		guarded.Control = g.control
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+// control is called after the name resolution, right before connecting
+func (g addressGuard) control(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !g.isAllowed(ip) {
+		log.Error("container.address_guard ", "blocked outbound connection to ", address)
+		return fmt.Errorf("%w: %s", ErrDisallowedAddress, host)
+	}
+	return nil
+}
+
+func (g addressGuard) isAllowed(ip net.IP) bool {
+	for _, network := range g.allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range g.restricted {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package container
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAddressGuardBlocksInternalAddresses(t *testing.T) {
+	guard := newAddressGuard(bootstrap.OutboundConfig{BlockPrivateNetworks: true})
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1",
+		"169.254.169.254", "224.0.0.1", "0.0.0.0", "100.64.0.1", "::1", "fe80::1", "fd00::1"} {
+		assert.False(t, guard.isAllowed(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:4700::1111"} {
+		assert.True(t, guard.isAllowed(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestAddressGuardAllowList(t *testing.T) {
+	guard := newAddressGuard(bootstrap.OutboundConfig{
+		BlockPrivateNetworks: true,
+		AllowedNetworks:      []string{"10.0.0.0/8", "192.168.1.5"},
+	})
+
+	assert.True(t, guard.isAllowed(net.ParseIP("10.20.30.40")))
+	assert.True(t, guard.isAllowed(net.ParseIP("192.168.1.5")))
+	assert.False(t, guard.isAllowed(net.ParseIP("192.168.1.6")))
+}
+
+func TestHttpClientRejectsLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := getHttpClient(bootstrap.OutboundConfig{DialTimeout: 1000, BlockPrivateNetworks: true}, nil, nil)
+	_, err := client.Get(server.URL)
+	assert.True(t, errors.Is(err, ErrDisallowedAddress))
+
+	// the host name is allowed, the address it redirects to is not
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+	defer redirect.Close()
+	client = getHttpClient(bootstrap.OutboundConfig{
+		DialTimeout:          1000,
+		BlockPrivateNetworks: true,
+		AllowedNetworks:      []string{"localhost"},
+	}, nil, nil)
+	_, err = client.Get(strings.Replace(redirect.URL, "127.0.0.1", "localhost", 1))
+	assert.True(t, errors.Is(err, ErrDisallowedAddress))
+}
@@ -0,0 +1,232 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/web-page-analysis/bootstrap"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrHostWait is returned when the caller gives up while the request waits for its host,
+// the host did not fail so the request is not retried
+var ErrHostWait = errors.New("gave up waiting for the host")
+
+// hostLimiter caps the requests in flight to a host and spaces out their starts,
+// a host answering 429 is paused for the time it asks for
+type hostLimiter struct {
+	lock           sync.Mutex
+	hosts          map[string]*hostState
+	maxConcurrency int
+	minDelay       time.Duration
+	maxRetryAfter  time.Duration
+	retries        int
+}
+
+type hostState struct {
+	// slots is nil when the concurrency is not limited
+	slots chan struct{}
+	// next is the earliest start of the next request
+	next time.Time
+	// users counts the requests holding or waiting for the state
+	users int
+}
+
+// limitedTransport sends every request, redirects included, through the limiter of its host,
+// the timeout of a request starts once it has its slot so the wait is not counted against it
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *hostLimiter
+	timeout time.Duration
+}
+
+// releaseBody frees the host slot once the response body is closed
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func newHostLimiter(conf bootstrap.HostLimitConfig) *hostLimiter {
+	return &hostLimiter{
+		hosts:          make(map[string]*hostState),
+		maxConcurrency: int(conf.MaxConcurrency),
+		minDelay:       time.Millisecond * time.Duration(conf.MinDelay),
+		maxRetryAfter:  time.Millisecond * time.Duration(conf.MaxRetryAfter),
+		retries:        int(conf.RateLimitRetries),
+	}
+}
+
+// acquire waits for a free slot of the host and for its turn to start,
+// the returned func gives the slot back
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	host = strings.ToLower(host)
+	l.lock.Lock()
+	state, ok := l.hosts[host]
+	if !ok {
+		l.sweep()
+		state = &hostState{}
+		if l.maxConcurrency > 0 {
+			state.slots = make(chan struct{}, l.maxConcurrency)
+		}
+		l.hosts[host] = state
+	}
+	state.users++
+	l.lock.Unlock()
+
+	done := func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		state.users--
+		if state.users == 0 && time.Now().After(state.next) {
+			delete(l.hosts, host)
+		}
+	}
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+		case <-ctx.Done():
+			done()
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if state.slots != nil {
+			<-state.slots
+		}
+		done()
+	}
+
+	l.lock.Lock()
+	start := time.Now()
+	if state.next.After(start) {
+		start = state.next
+	}
+	state.next = start.Add(l.minDelay)
+	l.lock.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// sweep forgets the idle hosts left behind with a delay which is over by now,
+// the lock must be held
+func (l *hostLimiter) sweep() {
+	now := time.Now()
+	for host, state := range l.hosts {
+		if state.users == 0 && now.After(state.next) {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+// pause holds back the next requests to the host until the given time
+func (l *hostLimiter) pause(host string, until time.Time) {
+	host = strings.ToLower(host)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if state, ok := l.hosts[host]; ok && until.After(state.next) {
+		state.next = until
+	}
+}
+
+func (t limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		release, err := t.limiter.acquire(req.Context(), req.URL.Host)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrHostWait, err)
+		}
+		resp, err := t.send(req, release)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if ok && wait <= t.limiter.maxRetryAfter {
+				t.limiter.pause(req.URL.Host, time.Now().Add(wait))
+				// only requests without a body, or with one which can be read again, are sent again
+				if attempt < t.limiter.retries && (req.Body == nil || req.GetBody != nil) && fitsDeadline(req, wait) {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					if req.GetBody != nil {
+						if req.Body, err = req.GetBody(); err != nil {
+							return nil, err
+						}
+					}
+					continue
+				}
+			}
+		}
+		return resp, nil
+	}
+}
+
+// send makes the request holding the slot, the slot is given back and the timeout
+// stopped when the request fails or its body is closed
+func (t limitedTransport) send(req *http.Request, release func()) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+		req = req.WithContext(ctx)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: func() {
+		cancel()
+		release()
+	}}
+	return resp, nil
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// fitsDeadline tells if the request can still be sent after waiting for the deadline of the caller
+func fitsDeadline(req *http.Request, wait time.Duration) bool {
+	deadline, ok := req.Context().Deadline()
+	return !ok || time.Now().Add(wait).Before(deadline)
+}
+
+// retryAfter reads the header as seconds or as an http date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
@@ -0,0 +1,173 @@
+package container
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/util/testserver"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterCapsConcurrency(t *testing.T) {
+	server := testserver.NewConcurrencyServer(20 * time.Millisecond)
+	defer server.Close()
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MaxConcurrency: 2})
+	client := getHttpClient(bootstrap.OutboundConfig{DialTimeout: 1000}, nil, limiter)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), server.MaxInFlight())
+	// idle hosts are forgotten
+	assert.Empty(t, limiter.hosts)
+}
+
+func TestHostLimiterWaitIsNotTimedOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MaxConcurrency: 2, MinDelay: 10})
+	// 30 requests two at a time take far longer than the timeout of each one
+	client := getHttpClient(bootstrap.OutboundConfig{DialTimeout: 200}, nil, limiter)
+
+	var failed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				atomic.AddInt32(&failed, 1)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&failed))
+}
+
+func TestHostLimiterTimesOutTheRequest(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MaxConcurrency: 1})
+	client := getHttpClient(bootstrap.OutboundConfig{DialTimeout: 50}, nil, limiter)
+
+	start := time.Now()
+	_, err := client.Get(server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	// the slot is given back with the failed request
+	assert.Empty(t, limiter.hosts)
+}
+
+func TestHostLimiterSpacesRequests(t *testing.T) {
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MinDelay: 50})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.acquire(ctx, "abc.com")
+		assert.NoError(t, err)
+		release()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// another host does not wait for abc.com
+	start = time.Now()
+	release, err := limiter.acquire(ctx, "xyz.com")
+	assert.NoError(t, err)
+	release()
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestHostLimiterStopsWaitingOnCancel(t *testing.T) {
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MaxConcurrency: 1})
+	release, err := limiter.acquire(context.Background(), "abc.com")
+	assert.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "abc.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHostLimiterRetriesAfterTooManyRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MaxRetryAfter: 2000, RateLimitRetries: 1})
+	client := getHttpClient(bootstrap.OutboundConfig{DialTimeout: 3000}, nil, limiter)
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestHostLimiterKeepsTooManyRequestsOverTheCap(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MaxRetryAfter: 2000, RateLimitRetries: 1})
+	client := getHttpClient(bootstrap.OutboundConfig{DialTimeout: 1000}, nil, limiter)
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	wait, ok := retryAfter("30", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	wait, ok = retryAfter("Wed, 01 May 2024 10:01:00 GMT", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, wait)
+
+	_, ok = retryAfter("soon", now)
+	assert.False(t, ok)
+	_, ok = retryAfter("", now)
+	assert.False(t, ok)
+}
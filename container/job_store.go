@@ -0,0 +1,204 @@
+package container
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJobMaxQueued = 100
+	defaultJobMaxStored = 100
+)
+
+// ErrJobQueueFull is returned by Create when job_max_queued jobs are already waiting
+var ErrJobQueueFull = errors.New("too many analysis jobs are queued, try again later")
+
+type JobStore interface {
+	// Create stores the job as a new queued one, with its kind and request set by the caller,
+	// it fails with ErrJobQueueFull when the queue is full. A job given as completed,
+	// a result kept to be paged through, is stored as it is and the oldest of them
+	// is dropped once job_max_stored are kept
+	Create(job domain.Job, cancel context.CancelFunc) (domain.Job, error)
+	Get(id string) (domain.Job, bool)
+	Update(id string, fn func(job *domain.Job))
+	Cancel(id string) (job domain.Job, found bool)
+	// Acquire blocks until a job slot is free or the ctx is done
+	Acquire(ctx context.Context) error
+	Release()
+}
+
+type jobEntry struct {
+	job    domain.Job
+	cancel context.CancelFunc
+	// stored is set for a result kept to be paged through
+	stored bool
+}
+
+type jobStore struct {
+	lock      sync.RWMutex
+	jobs      map[string]*jobEntry
+	slots     chan struct{}
+	retention time.Duration
+	maxQueued int
+	maxStored int
+}
+
+func InitJobStore(conf bootstrap.Config) JobStore {
+	concurrency := conf.AppConfig.JobConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	maxQueued := int(conf.AppConfig.JobMaxQueued)
+	if maxQueued <= 0 {
+		maxQueued = defaultJobMaxQueued
+	}
+	maxStored := int(conf.AppConfig.JobMaxStored)
+	if maxStored <= 0 {
+		maxStored = defaultJobMaxStored
+	}
+	return &jobStore{
+		jobs:      make(map[string]*jobEntry),
+		slots:     make(chan struct{}, concurrency),
+		retention: time.Millisecond * time.Duration(conf.AppConfig.JobRetention),
+		maxQueued: maxQueued,
+		maxStored: maxStored,
+	}
+}
+
+func (s *jobStore) Create(job domain.Job, cancel context.CancelFunc) (domain.Job, error) {
+	now := time.Now()
+	job.Id = newJobID()
+	stored := job.Status == domain.JobStatusCompleted
+	if !stored {
+		job.Status = domain.JobStatusQueued
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.purge(now)
+	if !stored && s.queued() >= s.maxQueued {
+		return domain.Job{}, ErrJobQueueFull
+	}
+	if stored {
+		s.dropOldestStored()
+	}
+	s.jobs[job.Id] = &jobEntry{job: job, cancel: cancel, stored: stored}
+	return copyJob(job), nil
+}
+
+func (s *jobStore) Get(id string) (domain.Job, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	entry, ok := s.jobs[id]
+	if !ok {
+		return domain.Job{}, false
+	}
+	return copyJob(entry.job), true
+}
+
+// Update applies fn to the stored job, finished jobs are left untouched
+// so a late result cannot overwrite a cancellation
+func (s *jobStore) Update(id string, fn func(job *domain.Job)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry, ok := s.jobs[id]
+	if !ok || entry.job.IsFinished() {
+		return
+	}
+	fn(&entry.job)
+	entry.job.UpdatedAt = time.Now()
+}
+
+func (s *jobStore) Cancel(id string) (job domain.Job, found bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry, ok := s.jobs[id]
+	if !ok {
+		return job, false
+	}
+	if !entry.job.IsFinished() {
+		entry.job.Status = domain.JobStatusCancelled
+		entry.job.UpdatedAt = time.Now()
+		if entry.cancel != nil {
+			entry.cancel()
+		}
+	}
+	return copyJob(entry.job), true
+}
+
+func (s *jobStore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *jobStore) Release() {
+	<-s.slots
+}
+
+// purge drops the finished jobs older than the retention period,
+// the caller must hold the lock
+func (s *jobStore) purge(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	for id, entry := range s.jobs {
+		if entry.job.IsFinished() && now.Sub(entry.job.UpdatedAt) > s.retention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// queued counts the jobs waiting for a slot, the caller must hold the lock
+func (s *jobStore) queued() int {
+	count := 0
+	for _, entry := range s.jobs {
+		if entry.job.Status == domain.JobStatusQueued {
+			count++
+		}
+	}
+	return count
+}
+
+// dropOldestStored makes room for one more stored result,
+// the caller must hold the lock
+func (s *jobStore) dropOldestStored() {
+	var (
+		count  int
+		oldest *jobEntry
+	)
+	for _, entry := range s.jobs {
+		if !entry.stored {
+			continue
+		}
+		count++
+		if oldest == nil || entry.job.CreatedAt.Before(oldest.job.CreatedAt) {
+			oldest = entry
+		}
+	}
+	if count >= s.maxStored {
+		delete(s.jobs, oldest.job.Id)
+	}
+}
+
+// copyJob returns a copy which does not share the progress slice with the store
+func copyJob(job domain.Job) domain.Job {
+	job.Progress.CompletedPhases = append([]string{}, job.Progress.CompletedPhases...)
+	return job
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
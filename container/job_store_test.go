@@ -0,0 +1,119 @@
+package container
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"testing"
+	"time"
+)
+
+func TestJobStoreLifecycle(t *testing.T) {
+	store := InitJobStore(bootstrap.Config{})
+	cancelled := false
+	job, err := store.Create(domain.Job{Kind: domain.JobKindAnalysis, Request: &domain.AnalyserRequest{Url: "http://abc.com"}},
+		func() { cancelled = true })
+	assert.NoError(t, err)
+	assert.Equal(t, domain.JobStatusQueued, job.Status)
+	assert.Len(t, job.Id, 32)
+
+	store.Update(job.Id, func(job *domain.Job) {
+		job.Status = domain.JobStatusRunning
+		job.Progress.CompletedPhases = append(job.Progress.CompletedPhases, "title")
+	})
+	stored, found := store.Get(job.Id)
+	assert.True(t, found)
+	assert.Equal(t, domain.JobStatusRunning, stored.Status)
+	// the copy does not share the progress with the store
+	stored.Progress.CompletedPhases[0] = "changed"
+	stored, _ = store.Get(job.Id)
+	assert.Equal(t, []string{"title"}, stored.Progress.CompletedPhases)
+
+	stored, found = store.Cancel(job.Id)
+	assert.True(t, found)
+	assert.True(t, cancelled)
+	assert.Equal(t, domain.JobStatusCancelled, stored.Status)
+
+	// a late result does not overwrite the cancellation
+	store.Update(job.Id, func(job *domain.Job) {
+		job.Status = domain.JobStatusCompleted
+	})
+	stored, _ = store.Get(job.Id)
+	assert.Equal(t, domain.JobStatusCancelled, stored.Status)
+
+	_, found = store.Get("unknown")
+	assert.False(t, found)
+	_, found = store.Cancel("unknown")
+	assert.False(t, found)
+}
+
+func TestJobStorePurgesFinishedJobs(t *testing.T) {
+	store := InitJobStore(bootstrap.Config{AppConfig: bootstrap.AppConfig{JobRetention: 10}})
+	finished, _ := store.Create(domain.Job{}, nil)
+	store.Update(finished.Id, func(job *domain.Job) {
+		job.Status = domain.JobStatusCompleted
+	})
+	queued, _ := store.Create(domain.Job{}, nil)
+
+	time.Sleep(20 * time.Millisecond)
+	_, err := store.Create(domain.Job{}, nil)
+	assert.NoError(t, err)
+	_, found := store.Get(finished.Id)
+	assert.False(t, found)
+	// an unfinished job is kept however old it is
+	_, found = store.Get(queued.Id)
+	assert.True(t, found)
+}
+
+func TestJobStoreRefusesJobsWhenTheQueueIsFull(t *testing.T) {
+	store := InitJobStore(bootstrap.Config{AppConfig: bootstrap.AppConfig{JobMaxQueued: 2}})
+	first, err := store.Create(domain.Job{}, nil)
+	assert.NoError(t, err)
+	_, err = store.Create(domain.Job{}, nil)
+	assert.NoError(t, err)
+	_, err = store.Create(domain.Job{}, nil)
+	assert.ErrorIs(t, err, ErrJobQueueFull)
+	// a stored result does not wait for anything
+	stored, err := store.Create(domain.Job{Status: domain.JobStatusCompleted}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.JobStatusCompleted, stored.Status)
+
+	// a job leaving the queue makes room for another
+	store.Update(first.Id, func(job *domain.Job) {
+		job.Status = domain.JobStatusRunning
+	})
+	_, err = store.Create(domain.Job{}, nil)
+	assert.NoError(t, err)
+}
+
+func TestJobStoreBoundsConcurrency(t *testing.T) {
+	store := InitJobStore(bootstrap.Config{AppConfig: bootstrap.AppConfig{JobConcurrency: 1}})
+	assert.NoError(t, store.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, store.Acquire(ctx), context.DeadlineExceeded)
+
+	store.Release()
+	assert.NoError(t, store.Acquire(context.Background()))
+}
+
+func TestJobStoreKeepsFewStoredResults(t *testing.T) {
+	store := InitJobStore(bootstrap.Config{AppConfig: bootstrap.AppConfig{JobMaxStored: 2}})
+	queued, err := store.Create(domain.Job{}, nil)
+	assert.NoError(t, err)
+	first, _ := store.Create(domain.Job{Status: domain.JobStatusCompleted}, nil)
+	time.Sleep(time.Millisecond)
+	second, _ := store.Create(domain.Job{Status: domain.JobStatusCompleted}, nil)
+	time.Sleep(time.Millisecond)
+	third, _ := store.Create(domain.Job{Status: domain.JobStatusCompleted}, nil)
+
+	// the oldest stored result makes room, the jobs are not touched
+	_, found := store.Get(first.Id)
+	assert.False(t, found)
+	for _, id := range []string{queued.Id, second.Id, third.Id} {
+		_, found = store.Get(id)
+		assert.True(t, found)
+	}
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"container/list"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLinkCacheTTL        = 5 * time.Minute
+	defaultLinkCacheMaxEntries = 10000
+)
+
+type LinkCache interface {
+	// Get returns the result of the link checked earlier when it is not expired
+	Get(rawURL string) (domain.LinkDetail, bool)
+	Set(rawURL string, detail domain.LinkDetail)
+}
+
+type linkEntry struct {
+	key       string
+	detail    domain.LinkDetail
+	checkedAt time.Time
+}
+
+// linkCache keeps the entries in a list ordered from the most to the least recently used
+type linkCache struct {
+	lock       sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	ttl        time.Duration
+	maxEntries int
+}
+
+func InitLinkCache(conf bootstrap.Config) LinkCache {
+	ttl := time.Millisecond * time.Duration(conf.AppConfig.LinkCache.TTL)
+	if ttl <= 0 {
+		ttl = defaultLinkCacheTTL
+	}
+	maxEntries := int(conf.AppConfig.LinkCache.MaxEntries)
+	if maxEntries <= 0 {
+		maxEntries = defaultLinkCacheMaxEntries
+	}
+	return &linkCache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *linkCache) Get(rawURL string) (domain.LinkDetail, bool) {
+	key := linkCacheKey(rawURL)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return domain.LinkDetail{}, false
+	}
+	entry := element.Value.(*linkEntry)
+	if time.Since(entry.checkedAt) > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return domain.LinkDetail{}, false
+	}
+	c.order.MoveToFront(element)
+	return entry.detail, true
+}
+
+func (c *linkCache) Set(rawURL string, detail domain.LinkDetail) {
+	key := linkCacheKey(rawURL)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value = &linkEntry{key: key, detail: detail, checkedAt: time.Now()}
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&linkEntry{key: key, detail: detail, checkedAt: time.Now()})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*linkEntry).key)
+	}
+}
+
+// linkCacheKey spells the same url the same way, the scheme and the host are
+// lower cased, the default port and the fragment are dropped
+func linkCacheKey(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if parsed.Scheme == "http" && port == "80" || parsed.Scheme == "https" && port == "443" {
+		port = ""
+	}
+	switch {
+	case port != "":
+		host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		// an ipv6 address keeps its brackets
+		host = "[" + host + "]"
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	if parsed.Path == "" {
+		parsed.Path = "/"
+		parsed.RawPath = ""
+	}
+	return parsed.String()
+}
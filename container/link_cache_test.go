@@ -0,0 +1,46 @@
+package container
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"testing"
+	"time"
+)
+
+func TestLinkCacheKey(t *testing.T) {
+	assert.Equal(t, "https://abc.com/", linkCacheKey("HTTPS://ABC.com:443"))
+	assert.Equal(t, "http://abc.com:8080/a?b=c", linkCacheKey("http://abc.com:8080/a?b=c#top"))
+	assert.Equal(t, "http://[::1]/", linkCacheKey("http://[::1]:80/"))
+	assert.Equal(t, "http://[::1]:8080/A", linkCacheKey("http://[::1]:8080/A"))
+}
+
+func TestLinkCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := InitLinkCache(bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		LinkCache: bootstrap.LinkCacheConfig{MaxEntries: 2},
+	}})
+	cache.Set("https://abc.com/a", domain.LinkDetail{StatusCode: 200})
+	cache.Set("https://abc.com/b", domain.LinkDetail{StatusCode: 404})
+	_, ok := cache.Get("https://ABC.com/a#top")
+	assert.True(t, ok)
+
+	cache.Set("https://abc.com/c", domain.LinkDetail{StatusCode: 500})
+	_, ok = cache.Get("https://abc.com/b")
+	assert.False(t, ok)
+	detail, ok := cache.Get("https://abc.com/a")
+	assert.True(t, ok)
+	assert.Equal(t, 200, detail.StatusCode)
+}
+
+func TestLinkCacheExpires(t *testing.T) {
+	cache := InitLinkCache(bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		LinkCache: bootstrap.LinkCacheConfig{TTL: 20},
+	}})
+	cache.Set("https://abc.com/a", domain.LinkDetail{StatusCode: 200})
+	_, ok := cache.Get("https://abc.com/a")
+	assert.True(t, ok)
+
+	time.Sleep(30 * time.Millisecond)
+	_, ok = cache.Get("https://abc.com/a")
+	assert.False(t, ok)
+}
@@ -2,36 +2,125 @@ package container
 
 import (
 	"context"
+	"crypto/tls"
 	"github.com/web-page-analysis/bootstrap"
+	"io"
 	"net"
 	"net/http"
 	"time"
 )
 
+const (
+	defaultMaxRedirects = 10
+)
+
 var (
 	connectionClient ConnectionClientConfig
 )
 
 type ConnectionClientConfig struct {
 	HttpClientDefault http.Client
+	// HttpClientUnverified accepts any certificate, it has its own transport
+	// so its connections are never reused by the verifying client
+	HttpClientUnverified http.Client
 }
 
 type outBoundConnection struct {
 	outboundConf bootstrap.OutboundConfig
+	retry        retryPolicy
 }
 
 func (o outBoundConnection) Get(ctx context.Context, url string) (*http.Response, error) {
 	client := connectionClient.HttpClientDefault
-	resp, err := client.Get(url)
+	resp, err := o.retry.do(ctx, func() (*http.Response, error) {
+		return send(ctx, client, http.MethodGet, url)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+
+}
+
+// GetUnverified does not fail on an invalid certificate, so the certificate
+// of the response can be inspected and reported instead
+func (o outBoundConnection) GetUnverified(ctx context.Context, url string) (*http.Response, error) {
+	client := connectionClient.HttpClientUnverified
+	resp, err := o.retry.do(ctx, func() (*http.Response, error) {
+		return send(ctx, client, http.MethodGet, url)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return resp, nil
+}
 
+// Check asks for the status of the url, with HEAD first when it is enabled, and falls back
+// to a GET when HEAD is not supported or breaks the connection, a GET body
+// reads no more than the configured number of bytes
+func (o outBoundConnection) Check(ctx context.Context, url string) (*http.Response, error) {
+	client := connectionClient.HttpClientDefault
+	conf := o.outboundConf.LinkCheck
+	if conf.HeadFirst {
+		resp, err := o.retry.do(ctx, func() (*http.Response, error) {
+			return send(ctx, client, http.MethodHead, url)
+		})
+		if !headFallback(conf, resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+	resp, err := o.retry.do(ctx, func() (*http.Response, error) {
+		return send(ctx, client, http.MethodGet, url)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &limitedBody{Reader: io.LimitReader(resp.Body, conf.MaxBodyBytes), body: resp.Body}
+	return resp, nil
 }
 
 type OutBoundConnection interface {
 	Get(ctx context.Context, url string) (*http.Response, error)
+	GetUnverified(ctx context.Context, url string) (*http.Response, error)
+	Check(ctx context.Context, url string) (*http.Response, error)
+}
+
+// limitedBody stops reading at the limit, a body read to its end on close
+// lets the connection be reused, a longer one is dropped with the connection
+type limitedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *limitedBody) Close() error {
+	io.Copy(io.Discard, b.Reader)
+	return b.body.Close()
+}
+
+// send makes the request with the ctx, so it stops as soon as the caller gives up
+func send(ctx context.Context, client http.Client, method string, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// headFallback tells if the answer to HEAD has to be asked again with GET,
+// a server which does not support HEAD either refuses it or drops the connection
+func headFallback(conf bootstrap.LinkCheckConfig, resp *http.Response, err error) bool {
+	if err != nil {
+		return retryErrorKind(err) == retryErrorConnection
+	}
+	for _, status := range conf.FallbackStatuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
 }
 
 func InitOutBoundConnection(conf bootstrap.Config) OutBoundConnection {
@@ -39,21 +128,55 @@ func InitOutBoundConnection(conf bootstrap.Config) OutBoundConnection {
 	initConnection(conf.OutboundConf)
 	return &outBoundConnection{
 		outboundConf: conf.OutboundConf,
+		retry:        newRetryPolicy(conf.OutboundConf.Retry),
 	}
 }
 
 func initConnection(timeoutConf bootstrap.OutboundConfig) {
 
-	connectionClient.HttpClientDefault = getHttpClient(timeoutConf)
+	// both clients share the limiter, a host is limited whichever client calls it
+	limiter := newHostLimiter(timeoutConf.HostLimits)
+	connectionClient.HttpClientDefault = getHttpClient(timeoutConf, nil, limiter)
+	connectionClient.HttpClientUnverified = getHttpClient(timeoutConf, &tls.Config{InsecureSkipVerify: true}, limiter)
 }
 
-func getHttpClient(to bootstrap.OutboundConfig) http.Client {
+// getHttpClient sends the requests through the limiter when one is given,
+// the limiter then times every request out itself instead of the client
+func getHttpClient(to bootstrap.OutboundConfig, tlsConfig *tls.Config, limiter *hostLimiter) http.Client {
+	guard := newAddressGuard(to)
+	timeout := time.Millisecond * time.Duration(to.DialTimeout)
+	var transport http.RoundTripper = &http.Transport{
+		DialContext: guard.dialContext(&net.Dialer{
+			Timeout: time.Millisecond * time.Duration(to.DialTimeout),
+		}),
+		TLSClientConfig: tlsConfig,
+	}
+	if limiter != nil {
+		transport = limitedTransport{base: transport, limiter: limiter, timeout: timeout}
+		timeout = 0
+	}
 	return http.Client{
-		Timeout: time.Millisecond * time.Duration(to.DialTimeout),
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout: time.Millisecond * time.Duration(to.DialTimeout),
-			}).DialContext,
-		},
+		Timeout:       timeout,
+		CheckRedirect: checkRedirect(int(to.MaxRedirects)),
+		Transport:     transport,
+	}
+}
+
+// checkRedirect stops following a chain which loops or is too long,
+// the last redirect response is returned as is so the chain can be reported
+func checkRedirect(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		for _, previous := range via {
+			if previous.URL.String() == req.URL.String() {
+				return http.ErrUseLastResponse
+			}
+		}
+		return nil
 	}
 }
@@ -0,0 +1,115 @@
+package container
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetUnverifiedAcceptsSelfSignedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	ctx := context.Background()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{DialTimeout: 1000}})
+
+	_, err := adapter.Get(ctx, server.URL)
+	assert.Error(t, err)
+
+	resp, err := adapter.GetUnverified(ctx, server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotNil(t, resp.TLS)
+	assert.NotEmpty(t, resp.TLS.PeerCertificates)
+}
+
+func TestCheckUsesHead(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{
+		DialTimeout: 1000,
+		LinkCheck:   bootstrap.LinkCheckConfig{HeadFirst: true, FallbackStatuses: []int{405}, MaxBodyBytes: 10},
+	}})
+
+	resp, err := adapter.Check(context.Background(), server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{http.MethodHead}, methods)
+}
+
+func TestCheckFallsBackToGet(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{
+		DialTimeout: 1000,
+		LinkCheck:   bootstrap.LinkCheckConfig{HeadFirst: true, FallbackStatuses: []int{405}, MaxBodyBytes: 10},
+	}})
+
+	resp, err := adapter.Check(context.Background(), server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{http.MethodHead, http.MethodGet}, methods)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Len(t, body, 10)
+}
+
+func TestCheckFallsBackWhenHeadDropsTheConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{
+		DialTimeout: 1000,
+		LinkCheck:   bootstrap.LinkCheckConfig{HeadFirst: true},
+	}})
+
+	resp, err := adapter.Check(context.Background(), server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, http.MethodGet, resp.Request.Method)
+}
+
+func TestGetStopsWithTheContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{DialTimeout: 5000}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := adapter.Get(ctx, server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
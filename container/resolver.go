@@ -6,7 +6,10 @@ import (
 )
 
 type Container struct {
-	OBAdapter OutBoundConnection
+	OBAdapter   OutBoundConnection
+	JobStore    JobStore
+	RobotsCache RobotsCache
+	LinkCache   LinkCache
 }
 
 func Resolver(ctx context.Context,
@@ -15,6 +18,9 @@ func Resolver(ctx context.Context,
 	outBoundConnectionAdapter := InitOutBoundConnection(conf)
 
 	return &Container{
-		OBAdapter: outBoundConnectionAdapter,
+		OBAdapter:   outBoundConnectionAdapter,
+		JobStore:    InitJobStore(conf),
+		RobotsCache: InitRobotsCache(conf),
+		LinkCache:   InitLinkCache(conf),
 	}
 }
@@ -0,0 +1,146 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"github.com/web-page-analysis/bootstrap"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	retryErrorTimeout           = "timeout"
+	retryErrorConnectionRefused = "connection_refused"
+	retryErrorConnection        = "connection"
+	retryErrorDNS               = "dns"
+)
+
+type attemptsKey struct{}
+
+// retryPolicy sends a request again, with an exponential backoff, while it fails
+// with a retryable status code or error and attempts are left
+type retryPolicy struct {
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	jitter      float64
+	statuses    map[int]bool
+	errorKinds  map[string]bool
+}
+
+func newRetryPolicy(conf bootstrap.RetryConfig) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts: int(conf.MaxAttempts),
+		baseBackoff: time.Millisecond * time.Duration(conf.BaseBackoff),
+		maxBackoff:  time.Millisecond * time.Duration(conf.MaxBackoff),
+		jitter:      conf.Jitter,
+		statuses:    make(map[int]bool),
+		errorKinds:  make(map[string]bool),
+	}
+	if policy.maxAttempts < 1 {
+		policy.maxAttempts = 1
+	}
+	for _, status := range conf.RetryableStatuses {
+		policy.statuses[status] = true
+	}
+	for _, kind := range conf.RetryableErrors {
+		policy.errorKinds[kind] = true
+	}
+	return policy
+}
+
+// Attempts counts the requests sent for a call, a HEAD and the GET after it add up
+type Attempts struct {
+	Sent int
+	// Retries are the requests sent again after a failure
+	Retries int
+}
+
+// WithAttempts returns a ctx in which the outbound calls record
+// how many times the request was sent, read it once the call is over
+func WithAttempts(ctx context.Context) (context.Context, *Attempts) {
+	attempts := new(Attempts)
+	return context.WithValue(ctx, attemptsKey{}, attempts), attempts
+}
+
+// do calls send until it succeeds, fails for good or runs out of attempts,
+// the last response or error is returned as it is
+func (p retryPolicy) do(ctx context.Context, send func() (*http.Response, error)) (*http.Response, error) {
+	attempts, _ := ctx.Value(attemptsKey{}).(*Attempts)
+	for attempt := 1; ; attempt++ {
+		if attempts != nil {
+			attempts.Sent++
+			if attempt > 1 {
+				attempts.Retries++
+			}
+		}
+		resp, err := send()
+		if attempt >= p.maxAttempts || !p.retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p retryPolicy) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return p.errorKinds[retryErrorKind(err)]
+	}
+	return resp != nil && p.statuses[resp.StatusCode]
+}
+
+// backoff doubles the base for every attempt made, up to the max,
+// then moves it by the jitter so the retries of many links spread out
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.baseBackoff
+	for i := 1; i < attempt && (p.maxBackoff <= 0 || backoff < p.maxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.maxBackoff > 0 && backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+	if p.jitter > 0 {
+		backoff += time.Duration(float64(backoff) * p.jitter * (2*rand.Float64() - 1))
+	}
+	return backoff
+}
+
+// retryErrorKind names the errors which may go away when tried again,
+// a blocked address, a bad certificate, a cancelled call or one which ran out
+// of time waiting for the host limiter are never retried
+func retryErrorKind(err error) string {
+	var (
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+	switch {
+	case errors.Is(err, ErrDisallowedAddress), errors.Is(err, ErrHostWait), errors.Is(err, context.Canceled):
+		return ""
+	case errors.As(err, &dnsErr):
+		return retryErrorDNS
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return retryErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return retryErrorConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return retryErrorConnection
+	default:
+		return ""
+	}
+}
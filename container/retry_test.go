@@ -0,0 +1,183 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func retryConfigForTest() bootstrap.RetryConfig {
+	return bootstrap.RetryConfig{
+		MaxAttempts:       3,
+		BaseBackoff:       10,
+		MaxBackoff:        50,
+		RetryableStatuses: []int{http.StatusServiceUnavailable},
+		RetryableErrors:   []string{retryErrorTimeout, retryErrorConnectionRefused},
+	}
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{
+		DialTimeout: 1000,
+		Retry:       retryConfigForTest(),
+	}})
+
+	ctx, attempts := WithAttempts(context.Background())
+	resp, err := adapter.Get(ctx, server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, attempts.Sent)
+	assert.Equal(t, 2, attempts.Retries)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{
+		DialTimeout: 1000,
+		Retry:       retryConfigForTest(),
+	}})
+
+	ctx, attempts := WithAttempts(context.Background())
+	resp, err := adapter.Get(ctx, server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 3, attempts.Sent)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRetrySkipsPermanentFailures(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{
+		DialTimeout: 1000,
+		Retry:       retryConfigForTest(),
+	}})
+
+	ctx, attempts := WithAttempts(context.Background())
+	resp, err := adapter.Get(ctx, server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 1, attempts.Sent)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	policy := newRetryPolicy(bootstrap.RetryConfig{
+		MaxAttempts: 5, BaseBackoff: 1000, RetryableErrors: []string{retryErrorConnectionRefused},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	ctx, attempts := WithAttempts(ctx)
+
+	_, err := policy.do(ctx, func() (*http.Response, error) {
+		return nil, syscall.ECONNREFUSED
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, attempts.Sent)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := newRetryPolicy(bootstrap.RetryConfig{BaseBackoff: 100, MaxBackoff: 350})
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 350*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, 350*time.Millisecond, policy.backoff(30))
+
+	policy.jitter = 0.5
+	for i := 0; i < 20; i++ {
+		backoff := policy.backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
+
+func TestRetryErrorKind(t *testing.T) {
+	assert.Equal(t, retryErrorDNS, retryErrorKind(&net.DNSError{Err: "no such host", Name: "abc.invalid"}))
+	assert.Equal(t, retryErrorTimeout, retryErrorKind(context.DeadlineExceeded))
+	assert.Equal(t, retryErrorConnectionRefused, retryErrorKind(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.Equal(t, retryErrorConnection, retryErrorKind(syscall.ECONNRESET))
+	assert.Empty(t, retryErrorKind(ErrDisallowedAddress))
+	assert.Empty(t, retryErrorKind(fmt.Errorf("%w: %w", ErrHostWait, context.DeadlineExceeded)))
+	assert.Empty(t, retryErrorKind(errors.New("x509: certificate signed by unknown authority")))
+}
+
+func TestRetryCountsHeadAndGetAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	adapter := InitOutBoundConnection(bootstrap.Config{OutboundConf: bootstrap.OutboundConfig{
+		DialTimeout: 1000,
+		Retry:       retryConfigForTest(),
+		LinkCheck:   bootstrap.LinkCheckConfig{HeadFirst: true, FallbackStatuses: []int{405}, MaxBodyBytes: 10},
+	}})
+
+	// the HEAD is sent once, the GET three times
+	ctx, attempts := WithAttempts(context.Background())
+	resp, err := adapter.Check(ctx, server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 4, attempts.Sent)
+	assert.Equal(t, 2, attempts.Retries)
+}
+
+func TestRetrySkipsTheHostWait(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	limiter := newHostLimiter(bootstrap.HostLimitConfig{MaxConcurrency: 1})
+	client := getHttpClient(bootstrap.OutboundConfig{DialTimeout: 1000}, nil, limiter)
+	policy := newRetryPolicy(retryConfigForTest())
+
+	// the only slot of the host is held
+	go client.Get(server.URL)
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the wait runs out on a deadline of its own, not the one of the caller
+	ctx, attempts := WithAttempts(context.Background())
+	_, err := policy.do(ctx, func() (*http.Response, error) {
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		return send(waitCtx, client, http.MethodGet, server.URL)
+	})
+	assert.ErrorIs(t, err, ErrHostWait)
+	assert.Equal(t, 1, attempts.Sent)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,80 @@
+package container
+
+import (
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRobotsCacheTTL = 10 * time.Minute
+)
+
+type RobotsCache interface {
+	// Get returns the robots.txt of the origin, fetch is called once
+	// when it is missing or expired, concurrent callers wait for that fetch.
+	// A result fetch does not keep is dropped and kept is false for its callers
+	Get(origin string, fetch func() (robots domain.RobotsTxt, keep bool)) (robots domain.RobotsTxt, kept bool)
+}
+
+type robotsEntry struct {
+	once      sync.Once
+	robots    domain.RobotsTxt
+	kept      bool
+	fetchedAt time.Time
+}
+
+type robotsCache struct {
+	lock    sync.Mutex
+	entries map[string]*robotsEntry
+	ttl     time.Duration
+}
+
+func InitRobotsCache(conf bootstrap.Config) RobotsCache {
+	ttl := time.Millisecond * time.Duration(conf.AppConfig.Robots.CacheTTL)
+	if ttl <= 0 {
+		ttl = defaultRobotsCacheTTL
+	}
+	return &robotsCache{
+		entries: make(map[string]*robotsEntry),
+		ttl:     ttl,
+	}
+}
+
+func (c *robotsCache) Get(origin string, fetch func() (domain.RobotsTxt, bool)) (domain.RobotsTxt, bool) {
+	now := time.Now()
+	c.lock.Lock()
+	entry, ok := c.entries[origin]
+	if !ok || c.expired(entry, now) {
+		c.purge(now)
+		entry = &robotsEntry{}
+		c.entries[origin] = entry
+	}
+	c.lock.Unlock()
+
+	entry.once.Do(func() {
+		entry.robots, entry.kept = fetch()
+		c.lock.Lock()
+		entry.fetchedAt = time.Now()
+		if !entry.kept && c.entries[origin] == entry {
+			delete(c.entries, origin)
+		}
+		c.lock.Unlock()
+	})
+	return entry.robots, entry.kept
+}
+
+// expired is false while the entry is being fetched
+func (c *robotsCache) expired(entry *robotsEntry, now time.Time) bool {
+	return !entry.fetchedAt.IsZero() && now.Sub(entry.fetchedAt) > c.ttl
+}
+
+// purge drops the expired entries, it is called with the lock held
+func (c *robotsCache) purge(now time.Time) {
+	for origin, entry := range c.entries {
+		if c.expired(entry, now) {
+			delete(c.entries, origin)
+		}
+	}
+}
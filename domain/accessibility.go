@@ -0,0 +1,8 @@
+package domain
+
+type Accessibility struct {
+	Lang         string    `json:"lang"`
+	ErrorCount   int       `json:"error_count"`
+	WarningCount int       `json:"warning_count"`
+	Findings     []Finding `json:"findings"`
+}
@@ -2,19 +2,66 @@ package domain
 
 type AnalyserRequest struct {
 	Url string `json:"url"`
+	// IncludeLinkDetails adds a record per checked link to the result
+	IncludeLinkDetails bool `json:"include_link_details,omitempty"`
+	// LinkFilter pages through the link records, it is read from the query
+	LinkFilter *LinkFilter `json:"-"`
+	// Analysers selects the analysers to run by name, all of them run when empty
+	Analysers []string `json:"analysers,omitempty"`
+	// BypassCache checks every link again instead of reusing the cached results
+	BypassCache bool `json:"bypass_cache,omitempty"`
+	// Timeout is the milliseconds the analysis may take, capped by analysis_timeout
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// HTMLAnalyserRequest carries the html of a page the server cannot reach,
+// it is analysed as it is, without fetching anything first
+type HTMLAnalyserRequest struct {
+	HTML string `json:"html"`
+	// BaseUrl resolves the relative links, the links and robots analysers only run with it
+	BaseUrl            string      `json:"base_url,omitempty"`
+	IncludeLinkDetails bool        `json:"include_link_details,omitempty"`
+	LinkFilter         *LinkFilter `json:"-"`
+	Analysers          []string    `json:"analysers,omitempty"`
+	BypassCache        bool        `json:"bypass_cache,omitempty"`
+	Timeout            int         `json:"timeout,omitempty"`
 }
 
 type AnalysisResult struct {
-	HTMLVersion  string         `json:"html_version"`
-	Title        string         `json:"title"`
-	Headings     map[string]int `json:"headings"`
-	Link         Link           `json:"link"`
-	HasLoginForm bool           `json:"has_login_form"`
+	HTMLVersion     string          `json:"html_version"`
+	Title           string          `json:"title"`
+	Headings        map[string]int  `json:"headings"`
+	Link            Link            `json:"link"`
+	HasLoginForm    bool            `json:"has_login_form"`
+	SEO             SEO             `json:"seo"`
+	StructuredData  StructuredData  `json:"structured_data"`
+	Accessibility   Accessibility   `json:"accessibility"`
+	Rules           Rules           `json:"rules"`
+	SecurityHeaders SecurityHeaders `json:"security_headers"`
+	TLS             TLS             `json:"tls"`
+	Robots          Robots          `json:"robots"`
+	// Redirect is the redirect chain of the page itself, if it was redirected
+	Redirect *RedirectChain `json:"redirect,omitempty"`
+	// Findings are about fetching the page itself, such as a redirect chain which never ends
+	Findings []Finding `json:"findings,omitempty"`
+	// Truncated is set when the analysis ran out of time or was cancelled,
+	// the links not checked by then are marked skipped
+	Truncated bool `json:"truncated"`
+	// JobId is the stored result of a paged analysis, its next pages are read from /jobs/{id}
+	JobId string `json:"job_id,omitempty"`
 }
 
 type Link struct {
-	InternalLinks         int      `json:"internal_links"`
-	ExternalLinks         int      `json:"external_links"`
-	InaccessibleLinkCount int      `json:"inaccessible_link_count"`
-	InaccessibleLink      []string `json:"inaccessible_link"`
+	InternalLinks         int             `json:"internal_links"`
+	ExternalLinks         int             `json:"external_links"`
+	NonHTTPLinks          int             `json:"non_http_links"`
+	InaccessibleLinkCount int             `json:"inaccessible_link_count"`
+	InaccessibleLink      []string        `json:"inaccessible_link"`
+	RedirectedLinks       []RedirectChain `json:"redirected_links,omitempty"`
+	RobotsDisallowedLinks []string        `json:"robots_disallowed_links"`
+	FlakyLinks            []string        `json:"flaky_links"`
+	Details               []LinkDetail    `json:"details,omitempty"`
+	Pagination            *Pagination     `json:"pagination,omitempty"`
+	// InternalLink holds the resolved internal urls, used by the crawler
+	InternalLink []string `json:"-"`
 }
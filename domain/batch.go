@@ -0,0 +1,44 @@
+package domain
+
+// BatchRequest analyses every url of the list, the options apply to each page
+type BatchRequest struct {
+	Urls        []string `json:"urls"`
+	Analysers   []string `json:"analysers,omitempty"`
+	BypassCache bool     `json:"bypass_cache,omitempty"`
+	// Timeout is the milliseconds each page may take, capped by analysis_timeout
+	Timeout int `json:"timeout,omitempty"`
+}
+
+type BatchResult struct {
+	Results []BatchItem  `json:"results"`
+	Summary BatchSummary `json:"summary"`
+}
+
+// BatchItem is the result of one url, or the error it failed with
+type BatchItem struct {
+	Url        string          `json:"url"`
+	StatusCode int64           `json:"status_code"`
+	Result     *AnalysisResult `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+type BatchSummary struct {
+	TotalUrls int `json:"total_urls"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Rows has a line per url, in the order of the request
+	Rows []BatchRow `json:"rows"`
+}
+
+type BatchRow struct {
+	Url               string `json:"url"`
+	StatusCode        int64  `json:"status_code"`
+	Title             string `json:"title"`
+	HTMLVersion       string `json:"html_version"`
+	InternalLinks     int    `json:"internal_links"`
+	ExternalLinks     int    `json:"external_links"`
+	InaccessibleLinks int    `json:"inaccessible_links"`
+	HasLoginForm      bool   `json:"has_login_form"`
+	Truncated         bool   `json:"truncated"`
+	Error             string `json:"error,omitempty"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+type CrawlRequest struct {
+	Url      string `json:"url"`
+	MaxDepth int    `json:"max_depth"`
+	MaxPages int    `json:"max_pages"`
+	// Analysers selects the analysers run on each page, the links, the title
+	// and the login form are always analysed for the summary
+	Analysers []string `json:"analysers,omitempty"`
+	// BypassCache checks every link again instead of reusing the cached results
+	BypassCache bool `json:"bypass_cache,omitempty"`
+}
+
+type CrawlResult struct {
+	Pages   []PageResult `json:"pages"`
+	Summary CrawlSummary `json:"summary"`
+	// Truncated is set when the crawl was cancelled before its limits were reached
+	Truncated bool `json:"truncated"`
+}
+
+type PageResult struct {
+	Url    string          `json:"url"`
+	Depth  int             `json:"depth"`
+	Result *AnalysisResult `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type CrawlSummary struct {
+	TotalPages         int      `json:"total_pages"`
+	FailedPages        int      `json:"failed_pages"`
+	BrokenLinkCount    int      `json:"broken_link_count"`
+	BrokenLinks        []string `json:"broken_links"`
+	PagesWithoutTitle  []string `json:"pages_without_title"`
+	PagesWithLoginForm []string `json:"pages_with_login_form"`
+}
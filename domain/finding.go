@@ -0,0 +1,19 @@
+package domain
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is an issue reported by one of the page checks
+type Finding struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// WcagCriterion and Selector are set by the accessibility checks
+	WcagCriterion string `json:"wcag_criterion,omitempty"`
+	Selector      string `json:"selector,omitempty"`
+	// Header is set by the security header checks
+	Header string `json:"header,omitempty"`
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+
+	JobKindAnalysis = "analysis"
+	JobKindBatch    = "batch"
+
+	// ErrorTypeDestinationNotAllowed is set for a url which resolves to an address
+	// blocked by the outbound address guard
+	ErrorTypeDestinationNotAllowed = "DESTINATION_NOT_ALLOWED"
+)
+
+// Job is an analysis of a page, or a batch of pages, run in the background,
+// the request and the result of its kind are set
+type Job struct {
+	Id           string           `json:"id"`
+	Kind         string           `json:"kind"`
+	Status       string           `json:"status"`
+	Request      *AnalyserRequest `json:"request,omitempty"`
+	BatchRequest *BatchRequest    `json:"batch_request,omitempty"`
+	Progress     JobProgress      `json:"progress"`
+	Result       *AnalysisResult  `json:"result,omitempty"`
+	BatchResult  *BatchResult     `json:"batch_result,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	ErrorCode    int64            `json:"error_code,omitempty"`
+	// ErrorType is the error_code of the error response the same failure gets outside a job
+	ErrorType string    `json:"error_type,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type JobProgress struct {
+	TotalPhases     int      `json:"total_phases"`
+	CompletedPhases []string `json:"completed_phases"`
+	LinksFound      int      `json:"links_found"`
+	LinksChecked    int      `json:"links_checked"`
+	// TotalPages and PagesDone count the pages of a batch
+	TotalPages int `json:"total_pages,omitempty"`
+	PagesDone  int `json:"pages_done,omitempty"`
+}
+
+// IsFinished reports whether the job reached a terminal status
+func (j Job) IsFinished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
@@ -0,0 +1,76 @@
+package domain
+
+const (
+	LinkErrorDNS               = "dns"
+	LinkErrorTimeout           = "timeout"
+	LinkErrorTLS               = "tls"
+	LinkErrorConnectionRefused = "connection_refused"
+	LinkErrorBlocked           = "blocked"
+	LinkErrorConnection        = "connection"
+	LinkErrorRedirect          = "redirect"
+	LinkErrorHTTP4xx           = "http_4xx"
+	LinkErrorHTTP5xx           = "http_5xx"
+	LinkErrorHTTPStatus        = "http_status"
+)
+
+type LinkDetail struct {
+	Url        string `json:"url"`
+	Href       string `json:"href"`
+	AnchorText string `json:"anchor_text"`
+	Type       string `json:"type"`
+	// Internal is Type internal, kept for the clients written before Type
+	Internal       bool           `json:"internal"`
+	StatusCode     int            `json:"status_code"`
+	Accessible     bool           `json:"accessible"`
+	ErrorCategory  string         `json:"error_category,omitempty"`
+	Error          string         `json:"error,omitempty"`
+	ResponseTimeMs int64          `json:"response_time_ms"`
+	ContentType    string         `json:"content_type,omitempty"`
+	Redirect       *RedirectChain `json:"redirect,omitempty"`
+	// Attempts is how many times the link was requested, a HEAD and the GET after it included,
+	// Retries the requests sent again after a failure, one on an accessible link means it is flaky
+	Attempts int `json:"attempts,omitempty"`
+	Retries  int `json:"retries,omitempty"`
+	// CacheHit is set when the result was taken from an earlier check of the link
+	CacheHit bool `json:"cache_hit,omitempty"`
+	// RobotsDisallowed is set when robots.txt disallows the link,
+	// Skipped when it was not checked because of that
+	RobotsDisallowed bool `json:"robots_disallowed,omitempty"`
+	Skipped          bool `json:"skipped,omitempty"`
+	// RobotsUnreachable is set when the robots.txt of the link host could not be
+	// fetched, the link is checked as if it was allowed
+	RobotsUnreachable bool `json:"robots_unreachable,omitempty"`
+}
+
+const (
+	LinkTypeInternal = "internal"
+	LinkTypeExternal = "external"
+	// LinkTypeNonHTTP covers mailto, tel, javascript, data and the other
+	// non http schemes, these links are not checked
+	LinkTypeNonHTTP = "non_http"
+
+	StatusClassError        = "error"
+	StatusClassInaccessible = "inaccessible"
+)
+
+// LinkFilter selects a page of the link records,
+// the empty fields do not filter
+type LinkFilter struct {
+	Page     int
+	PageSize int
+	// StatusClass is one of 2xx, 3xx, 4xx, 5xx, error or inaccessible
+	StatusClass string
+	// Type is one of the LinkType values
+	Type string
+	Host string
+}
+
+type Pagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	TotalItems int `json:"total_items"`
+	TotalPages int `json:"total_pages"`
+	// TotalInaccessible is the inaccessible link count of the page analysed,
+	// the one of the link section is narrowed down to the records of the page
+	TotalInaccessible int `json:"total_inaccessible"`
+}
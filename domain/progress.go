@@ -0,0 +1,16 @@
+package domain
+
+type PhaseEvent struct {
+	Phase string      `json:"phase"`
+	Value interface{} `json:"value"`
+}
+
+type LinkCheck struct {
+	Url        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Accessible bool   `json:"accessible"`
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+	CacheHit   bool   `json:"cache_hit,omitempty"`
+}
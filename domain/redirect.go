@@ -0,0 +1,19 @@
+package domain
+
+type RedirectHop struct {
+	Url        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+	Permanent  bool   `json:"permanent"`
+}
+
+type RedirectChain struct {
+	Url             string        `json:"url"`
+	Hops            []RedirectHop `json:"hops"`
+	FinalUrl        string        `json:"final_url"`
+	FinalStatusCode int           `json:"final_status_code"`
+	// Permanent is set when every hop of the chain is a permanent redirect
+	Permanent   bool `json:"permanent"`
+	Loop        bool `json:"loop"`
+	TooManyHops bool `json:"too_many_hops"`
+}
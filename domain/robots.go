@@ -0,0 +1,43 @@
+package domain
+
+// RobotsTxt is the parsed robots.txt of a host
+type RobotsTxt struct {
+	Url        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	// Unreachable is set on a server error or a failed fetch, everything is disallowed then
+	Unreachable bool `json:"unreachable"`
+	// FetchError is set when the request itself failed, the host may be down
+	// altogether so its links are still checked
+	FetchError string        `json:"fetch_error,omitempty"`
+	Groups     []RobotsGroup `json:"groups"`
+	Sitemaps   []string      `json:"sitemaps"`
+}
+
+type RobotsGroup struct {
+	UserAgents []string     `json:"user_agents"`
+	Rules      []RobotsRule `json:"rules"`
+	// CrawlDelay is in seconds
+	CrawlDelay float64 `json:"crawl_delay,omitempty"`
+}
+
+type RobotsRule struct {
+	Allow bool   `json:"allow"`
+	Path  string `json:"path"`
+}
+
+// Robots summarises the robots.txt of the page host for the configured user agent
+type Robots struct {
+	Url         string `json:"url"`
+	Found       bool   `json:"found"`
+	StatusCode  int    `json:"status_code"`
+	Unreachable bool   `json:"unreachable"`
+	UserAgent   string `json:"user_agent"`
+	// MatchedGroup is the user agent of the group applied, * for the default one
+	MatchedGroup string    `json:"matched_group,omitempty"`
+	Allow        []string  `json:"allow"`
+	Disallow     []string  `json:"disallow"`
+	CrawlDelay   float64   `json:"crawl_delay,omitempty"`
+	Sitemaps     []string  `json:"sitemaps"`
+	PageAllowed  bool      `json:"page_allowed"`
+	Findings     []Finding `json:"findings"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+const (
+	RuleStatusPass    = "pass"
+	RuleStatusFail    = "fail"
+	RuleStatusSkipped = "skipped"
+)
+
+type Rules struct {
+	Passed   int          `json:"passed"`
+	Failed   int          `json:"failed"`
+	Results  []RuleResult `json:"results"`
+	Findings []Finding    `json:"findings"`
+}
+
+type RuleResult struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Selector    string `json:"selector"`
+	Status      string `json:"status"`
+	// Matched is the number of elements the selector matched
+	Matched int `json:"matched"`
+	// Messages tell why the rule failed or could not run
+	Messages []string `json:"messages,omitempty"`
+}
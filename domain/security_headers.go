@@ -0,0 +1,25 @@
+package domain
+
+type SecurityHeaders struct {
+	// Grade goes from A+ down to F, Score from 100 down to 0
+	Grade    string         `json:"grade"`
+	Score    int            `json:"score"`
+	Headers  []HeaderResult `json:"headers"`
+	Cookies  []CookieResult `json:"cookies"`
+	Findings []Finding      `json:"findings"`
+}
+
+type HeaderResult struct {
+	Name    string `json:"name"`
+	Value   string `json:"value,omitempty"`
+	Present bool   `json:"present"`
+	// Passed is false when the header has an error or a warning finding
+	Passed bool `json:"passed"`
+}
+
+type CookieResult struct {
+	Name     string `json:"name"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"http_only"`
+	SameSite string `json:"same_site,omitempty"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+type SEO struct {
+	TitleLength       int        `json:"title_length"`
+	MetaDescription   string     `json:"meta_description"`
+	DescriptionLength int        `json:"description_length"`
+	Robots            string     `json:"robots"`
+	NoIndex           bool       `json:"noindex"`
+	NoFollow          bool       `json:"nofollow"`
+	Canonical         string     `json:"canonical"`
+	Hreflang          []Hreflang `json:"hreflang"`
+	H1Count           int        `json:"h1_count"`
+	Findings          []Finding  `json:"findings"`
+}
+
+type Hreflang struct {
+	Lang string `json:"lang"`
+	Url  string `json:"url"`
+}
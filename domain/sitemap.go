@@ -0,0 +1,44 @@
+package domain
+
+const (
+	SitemapTypeURLSet = "urlset"
+	SitemapTypeIndex  = "sitemapindex"
+
+	// where the sitemap was found
+	SitemapSourceRequest = "request"
+	SitemapSourceRobots  = "robots"
+	SitemapSourceDefault = "default"
+	SitemapSourceIndex   = "index"
+)
+
+type SitemapRequest struct {
+	// Url is the site, or a sitemap when it points to an xml file
+	Url string `json:"url"`
+	// MaxChecks limits the listed urls checked, it is capped by sitemap_max_checks
+	MaxChecks int `json:"max_checks"`
+	// BypassCache checks every url again instead of reusing the cached results
+	BypassCache bool `json:"bypass_cache,omitempty"`
+	// Timeout is the milliseconds the sitemap analysis may take, capped by analysis_timeout
+	Timeout int `json:"timeout,omitempty"`
+}
+
+type SitemapResult struct {
+	Sitemaps []SitemapFile `json:"sitemaps"`
+	// TotalUrls counts the distinct urls over all the sitemaps
+	TotalUrls        int          `json:"total_urls"`
+	CheckedUrls      int          `json:"checked_urls"`
+	InaccessibleUrls []string     `json:"inaccessible_urls"`
+	Checks           []LinkDetail `json:"checks"`
+	Findings         []Finding    `json:"findings"`
+}
+
+type SitemapFile struct {
+	Url        string    `json:"url"`
+	Source     string    `json:"source"`
+	Type       string    `json:"type,omitempty"`
+	StatusCode int       `json:"status_code"`
+	Compressed bool      `json:"compressed"`
+	UrlCount   int       `json:"url_count"`
+	Error      string    `json:"error,omitempty"`
+	Findings   []Finding `json:"findings"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+const (
+	StructuredSourceJSONLD    = "json_ld"
+	StructuredSourceMicrodata = "microdata"
+)
+
+type StructuredData struct {
+	OpenGraph   map[string][]string    `json:"open_graph"`
+	TwitterCard map[string]string      `json:"twitter_card"`
+	JSONLD      []StructuredItem       `json:"json_ld"`
+	Microdata   []StructuredItem       `json:"microdata"`
+	ParseErrors []StructuredParseError `json:"parse_errors"`
+	Findings    []Finding              `json:"findings"`
+}
+
+// StructuredItem is a schema.org object found in json-ld or microdata
+type StructuredItem struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type StructuredParseError struct {
+	Source string `json:"source"`
+	// Index is the position of the block in the page
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
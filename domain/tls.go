@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+type TLS struct {
+	// Enabled is false when the page is not served over https
+	Enabled     bool   `json:"enabled"`
+	Version     string `json:"version,omitempty"`
+	CipherSuite string `json:"cipher_suite,omitempty"`
+	// HostnameMatch tells whether the leaf certificate covers the page host
+	HostnameMatch bool `json:"hostname_match"`
+	// Trusted tells whether the chain verifies against the system roots
+	Trusted      bool          `json:"trusted"`
+	Certificates []Certificate `json:"certificates"`
+	Findings     []Finding     `json:"findings"`
+}
+
+// Certificate is one certificate of the chain, the leaf comes first
+type Certificate struct {
+	Subject         string    `json:"subject"`
+	Issuer          string    `json:"issuer"`
+	SANs            []string  `json:"sans"`
+	NotBefore       time.Time `json:"not_before"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	SelfSigned      bool      `json:"self_signed"`
+}
@@ -2,9 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/cli"
 	"github.com/web-page-analysis/container"
-	"github.com/web-page-analysis/server"
 	"os"
 	"os/signal"
 	"syscall"
@@ -22,13 +23,13 @@ func main() {
 	// config loader
 	conf, err := bootstrap.InitConfig()
 	if err != nil {
-		return
+		fmt.Fprintf(os.Stderr, "error in loading the config: %v\n", err)
+		os.Exit(cli.ExitError)
 	}
 
 	// container resolver
 	ctr := container.Resolver(ctx, conf)
 
-	// server start
-	server.InitRouter(ctx, conf, *ctr)
-	<-ctx.Done()
+	// the server is started without a command, it is stopped gracefully on a signal
+	os.Exit(cli.Run(ctx, os.Args[1:], conf, *ctr, os.Stdout, os.Stderr))
 }
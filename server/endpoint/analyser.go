@@ -1,8 +1,8 @@
 package endpoint
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/web-page-analysis/bootstrap"
@@ -26,7 +26,7 @@ func NewAnalyser(ctr container.Container, config bootstrap.Config) *Analyser {
 }
 
 func (a Analyser) Analyse(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	log.WithContext(ctx).Info("start to analyse the web pages")
 
 	// unmarshal the request
@@ -38,8 +38,18 @@ func (a Analyser) Analyse(w http.ResponseWriter, r *http.Request) {
 			err), w)
 		return
 	}
+	analyserRequest.LinkFilter, err = parseLinkFilter(r)
+	if err != nil {
+		erro.BadRequestError(fmt.Sprintf("ERROR in query parameters, err: %+v",
+			err), w)
+		return
+	}
 	analyser := service.NewAnalyser(a.container, a.config)
 	result, statusCode, err := analyser.WebAnalyser(ctx, analyserRequest)
+	if errors.Is(err, container.ErrDisallowedAddress) {
+		erro.DestinationNotAllowedError(fmt.Sprintf("err: %+v", err), w)
+		return
+	}
 	if err != nil {
 		erro.GeneralError(fmt.Sprintf("err: %+v",
 			err), "error in analysing the webpage", statusCode, w)
@@ -55,3 +65,48 @@ func (a Analyser) Analyse(w http.ResponseWriter, r *http.Request) {
 	w.Write(raw)
 	return
 }
+
+// AnalyseHTML analyses the html sent in the request, as json or as a multipart upload,
+// for the pages the server cannot reach
+func (a Analyser) AnalyseHTML(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log.WithContext(ctx).Info("start to analyse the supplied html")
+
+	if maxSize := a.config.AppConfig.MaxHTMLSize; maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	}
+	htmlRequest, err := parseHTMLRequest(r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "html is larger than the allowed size", http.StatusRequestEntityTooLarge, w)
+		return
+	}
+	if err != nil {
+		log.Errorf("ERROR decoding request body, err: %+v", err)
+		erro.BadRequestError(fmt.Sprintf("ERROR decoding request body, err: %+v",
+			err), w)
+		return
+	}
+	htmlRequest.LinkFilter, err = parseLinkFilter(r)
+	if err != nil {
+		erro.BadRequestError(fmt.Sprintf("ERROR in query parameters, err: %+v",
+			err), w)
+		return
+	}
+	analyser := service.NewAnalyser(a.container, a.config)
+	result, statusCode, err := analyser.HTMLAnalyser(ctx, htmlRequest)
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in analysing the html", statusCode, w)
+		return
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in marshalling response", http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(raw)
+}
@@ -0,0 +1,157 @@
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	erro "github.com/web-page-analysis/server/error"
+	"github.com/web-page-analysis/service"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Batch struct {
+	// ctx is the server context, batch jobs outlive the request which created them
+	ctx       context.Context
+	container container.Container
+	config    bootstrap.Config
+}
+
+func NewBatch(ctx context.Context, ctr container.Container, config bootstrap.Config) *Batch {
+	return &Batch{
+		ctx:       ctx,
+		container: ctr,
+		config:    config,
+	}
+}
+
+// Analyse queues the batch as a job, its result is read from /jobs/{id}
+func (b Batch) Analyse(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(b.ctx).Info("start to analyse the batch of web pages")
+
+	if maxSize := b.config.AppConfig.MaxBatchSize; maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	}
+	batchRequest, err := parseBatchRequest(r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "url list is larger than the allowed size", http.StatusRequestEntityTooLarge, w)
+		return
+	}
+	if err != nil {
+		log.Errorf("ERROR decoding request body, err: %+v", err)
+		erro.BadRequestError(fmt.Sprintf("ERROR decoding request body, err: %+v",
+			err), w)
+		return
+	}
+	batch := service.NewBatch(b.container, b.config)
+	job, statusCode, err := batch.Submit(b.ctx, batchRequest)
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in creating the batch job", statusCode, w)
+		return
+	}
+	writeJob(job, http.StatusAccepted, w)
+}
+
+// parseBatchRequest reads the urls from a json object or array, from a text or csv body
+// or from a text or csv file uploaded as "file", the options of a non json body
+// are read from the query or the form
+func parseBatchRequest(r *http.Request) (req domain.BatchRequest, err error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		if err = r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return req, err
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return req, err
+		}
+		defer file.Close()
+		isCSV := strings.HasSuffix(strings.ToLower(header.Filename), ".csv") ||
+			header.Header.Get("Content-Type") == "text/csv"
+		if req.Urls, err = readUrlList(file, isCSV); err != nil {
+			return req, err
+		}
+	case "text/plain", "text/csv":
+		if req.Urls, err = readUrlList(r.Body, mediaType == "text/csv"); err != nil {
+			return req, err
+		}
+	default:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return req, err
+		}
+		// a bare array is the list of urls
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+			err = json.Unmarshal(trimmed, &req.Urls)
+			return req, err
+		}
+		err = json.Unmarshal(body, &req)
+		return req, err
+	}
+
+	if analysers := r.FormValue("analysers"); analysers != "" {
+		req.Analysers = strings.Split(analysers, ",")
+	}
+	req.BypassCache = r.FormValue("bypass_cache") == "true"
+	if timeout := r.FormValue("timeout"); timeout != "" {
+		if req.Timeout, err = strconv.Atoi(timeout); err != nil {
+			return req, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	return req, nil
+}
+
+// readUrlList takes a url per line, from a csv the url is the first column
+// and a header row is skipped, a first row which may be a url without its scheme
+// is kept so it fails as an invalid url rather than vanishing
+func readUrlList(reader io.Reader, isCSV bool) ([]string, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !isCSV {
+		return strings.Split(string(content), "\n"), nil
+	}
+
+	csvReader := csv.NewReader(bytes.NewReader(content))
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(records))
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+		first := strings.TrimSpace(record[0])
+		if i == 0 && isCSVHeader(first) {
+			continue
+		}
+		urls = append(urls, first)
+	}
+	if len(urls) == 0 && len(records) > 0 {
+		return nil, errors.New("no urls found in the first column")
+	}
+	return urls, nil
+}
+
+// isCSVHeader tells a column name such as "url" from a url, a url has a dot or a slash
+func isCSVHeader(column string) bool {
+	return !strings.ContainsAny(column, "./")
+}
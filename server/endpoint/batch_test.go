@@ -0,0 +1,138 @@
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseBatchRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/batch",
+		strings.NewReader(`{"urls": ["http://a.com", "http://b.com"], "analysers": ["title"], "timeout": 1000}`))
+	actual, err := parseBatchRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BatchRequest{
+		Urls:      []string{"http://a.com", "http://b.com"},
+		Analysers: []string{"title"},
+		Timeout:   1000,
+	}, actual)
+
+	// a bare array is the list of urls
+	req = httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(` ["http://a.com"]`))
+	actual, err = parseBatchRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://a.com"}, actual.Urls)
+
+	// the options of a text body are read from the query
+	req = httptest.NewRequest(http.MethodPost, "/batch?analysers=title,links&bypass_cache=true&timeout=500",
+		strings.NewReader("http://a.com\nhttp://b.com\n"))
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	actual, err = parseBatchRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BatchRequest{
+		Urls:        []string{"http://a.com", "http://b.com", ""},
+		Analysers:   []string{"title", "links"},
+		BypassCache: true,
+		Timeout:     500,
+	}, actual)
+
+	req = httptest.NewRequest(http.MethodPost, "/batch?timeout=soon", strings.NewReader("http://a.com"))
+	req.Header.Set("Content-Type", "text/plain")
+	_, err = parseBatchRequest(req)
+	assert.Error(t, err)
+
+	req = httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader("url,name\nhttp://a.com,A\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	actual, err = parseBatchRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://a.com"}, actual.Urls)
+
+	req = httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"urls": `))
+	_, err = parseBatchRequest(req)
+	assert.Error(t, err)
+}
+
+func TestParseBatchRequestFile(t *testing.T) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "urls.csv")
+	part.Write([]byte("url\nhttp://a.com\nhttp://b.com\n"))
+	writer.WriteField("analysers", "title")
+	writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/batch", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	actual, err := parseBatchRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://a.com", "http://b.com"}, actual.Urls)
+	assert.Equal(t, []string{"title"}, actual.Analysers)
+
+	// the form has no file
+	body = new(bytes.Buffer)
+	writer = multipart.NewWriter(body)
+	writer.WriteField("analysers", "title")
+	writer.Close()
+	req = httptest.NewRequest(http.MethodPost, "/batch", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	_, err = parseBatchRequest(req)
+	assert.Error(t, err)
+}
+
+func TestReadUrlList(t *testing.T) {
+	actual, err := readUrlList(strings.NewReader("http://a.com\r\nhttp://b.com"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://a.com\r", "http://b.com"}, actual)
+
+	// the header row is skipped, the other columns are ignored
+	actual, err = readUrlList(strings.NewReader("URL,Owner\nhttp://a.com,team a\n\"http://b.com\",team b\n"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://a.com", "http://b.com"}, actual)
+
+	// a first row without a scheme is a url, not a header, it is kept to fail as an invalid url
+	actual, err = readUrlList(strings.NewReader("abc.com\nhttp://a.com\n"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc.com", "http://a.com"}, actual)
+	actual, err = readUrlList(strings.NewReader("/relative/page\n"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/relative/page"}, actual)
+
+	_, err = readUrlList(strings.NewReader("url\n"), true)
+	assert.EqualError(t, err, "no urls found in the first column")
+
+	_, err = readUrlList(strings.NewReader("\"http://a.com\n"), true)
+	assert.Error(t, err)
+}
+
+func TestBatchQueuesAJob(t *testing.T) {
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{JobConcurrency: 1, BatchMaxUrls: 5, MaxBatchSize: 64}}
+	ctr := container.Container{
+		OBAdapter: container.InitOutBoundConnection(conf),
+		JobStore:  container.InitJobStore(conf),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batchObj := NewBatch(ctx, ctr, conf)
+
+	recorder := httptest.NewRecorder()
+	batchObj.Analyse(recorder, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`["not a url"]`)))
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"kind":"batch"`)
+
+	recorder = httptest.NewRecorder()
+	batchObj.Analyse(recorder, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[]`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// the body is limited to max_batch_size bytes
+	recorder = httptest.NewRecorder()
+	batchObj.Analyse(recorder, httptest.NewRequest(http.MethodPost, "/batch",
+		strings.NewReader(`["http://`+strings.Repeat("a", 64)+`.com"]`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
@@ -0,0 +1,61 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	erro "github.com/web-page-analysis/server/error"
+	"github.com/web-page-analysis/service"
+	"net/http"
+)
+
+type Crawler struct {
+	container container.Container
+	config    bootstrap.Config
+}
+
+func NewCrawler(ctr container.Container, config bootstrap.Config) *Crawler {
+	return &Crawler{
+		container: ctr,
+		config:    config,
+	}
+}
+
+func (c Crawler) Crawl(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log.WithContext(ctx).Info("start to crawl the web site")
+
+	// unmarshal the request
+	var crawlRequest domain.CrawlRequest
+	err := json.NewDecoder(r.Body).Decode(&crawlRequest)
+	if err != nil {
+		log.Errorf("ERROR decoding request body, err: %+v", err)
+		erro.BadRequestError(fmt.Sprintf("ERROR decoding request body, err: %+v",
+			err), w)
+		return
+	}
+	crawler := service.NewCrawler(c.container, c.config)
+	result, statusCode, err := crawler.Crawl(ctx, crawlRequest)
+	if errors.Is(err, container.ErrDisallowedAddress) {
+		erro.DestinationNotAllowedError(fmt.Sprintf("err: %+v", err), w)
+		return
+	}
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in crawling the website", statusCode, w)
+		return
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in marshalling response", http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(raw)
+	return
+}
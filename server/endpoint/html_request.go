@@ -0,0 +1,58 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/web-page-analysis/domain"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// multipart parts above this size are spooled to disk by the form parser
+	maxMultipartMemory = 32 << 20
+)
+
+// parseHTMLRequest reads the html to analyse either from a json body or from
+// a multipart form, where it is the uploaded "file" or the "html" field
+func parseHTMLRequest(r *http.Request) (req domain.HTMLAnalyserRequest, err error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		err = json.NewDecoder(r.Body).Decode(&req)
+		return req, err
+	}
+
+	if err = r.ParseMultipartForm(maxMultipartMemory); err != nil {
+		return req, err
+	}
+	req.HTML = r.FormValue("html")
+	file, _, err := r.FormFile("file")
+	switch {
+	case err == nil:
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return req, err
+		}
+		req.HTML = string(content)
+	case !errors.Is(err, http.ErrMissingFile):
+		return req, err
+	}
+
+	req.BaseUrl = r.FormValue("base_url")
+	req.IncludeLinkDetails = r.FormValue("include_link_details") == "true"
+	req.BypassCache = r.FormValue("bypass_cache") == "true"
+	if analysers := r.FormValue("analysers"); analysers != "" {
+		req.Analysers = strings.Split(analysers, ",")
+	}
+	if timeout := r.FormValue("timeout"); timeout != "" {
+		if req.Timeout, err = strconv.Atoi(timeout); err != nil {
+			return req, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,104 @@
+package endpoint
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// multipartForTest builds a form with the fields and, when content is given, the uploaded "file"
+func multipartForTest(t *testing.T, fields map[string]string, content string) *http.Request {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		assert.NoError(t, writer.WriteField(name, value))
+	}
+	if content != "" {
+		part, err := writer.CreateFormFile("file", "page.html")
+		assert.NoError(t, err)
+		part.Write([]byte(content))
+	}
+	assert.NoError(t, writer.Close())
+	req := httptest.NewRequest(http.MethodPost, "/analyse/html", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestParseHTMLRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/analyse/html", strings.NewReader(
+		`{"html": "<title>A</title>", "base_url": "http://abc.com", "analysers": ["title"], "include_link_details": true}`))
+	actual, err := parseHTMLRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.HTMLAnalyserRequest{
+		HTML:               "<title>A</title>",
+		BaseUrl:            "http://abc.com",
+		Analysers:          []string{"title"},
+		IncludeLinkDetails: true,
+	}, actual)
+
+	req = httptest.NewRequest(http.MethodPost, "/analyse/html", strings.NewReader(`{"html": `))
+	_, err = parseHTMLRequest(req)
+	assert.Error(t, err)
+}
+
+func TestParseHTMLRequestMultipart(t *testing.T) {
+	// the uploaded file takes over from the html field
+	actual, err := parseHTMLRequest(multipartForTest(t, map[string]string{
+		"html":                 "<title>Field</title>",
+		"base_url":             "http://abc.com",
+		"analysers":            "title,links",
+		"include_link_details": "true",
+		"bypass_cache":         "true",
+		"timeout":              "1000",
+	}, "<title>File</title>"))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.HTMLAnalyserRequest{
+		HTML:               "<title>File</title>",
+		BaseUrl:            "http://abc.com",
+		Analysers:          []string{"title", "links"},
+		IncludeLinkDetails: true,
+		BypassCache:        true,
+		Timeout:            1000,
+	}, actual)
+
+	actual, err = parseHTMLRequest(multipartForTest(t, map[string]string{"html": "<title>Field</title>"}, ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "<title>Field</title>", actual.HTML)
+	assert.Empty(t, actual.BaseUrl)
+
+	_, err = parseHTMLRequest(multipartForTest(t, map[string]string{"timeout": "soon"}, "<title>File</title>"))
+	assert.Error(t, err)
+}
+
+func TestAnalyseHTMLSizeLimit(t *testing.T) {
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{MaxHTMLSize: 64}}
+	analyserObj := NewAnalyser(container.Container{OBAdapter: container.InitOutBoundConnection(conf)}, conf)
+
+	recorder := httptest.NewRecorder()
+	analyserObj.AnalyseHTML(recorder, httptest.NewRequest(http.MethodPost, "/analyse/html",
+		strings.NewReader(`{"html": "<title>A</title>", "analysers": ["title"]}`)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"title":"A"`)
+
+	recorder = httptest.NewRecorder()
+	analyserObj.AnalyseHTML(recorder, httptest.NewRequest(http.MethodPost, "/analyse/html",
+		strings.NewReader(`{"html": "<title>`+strings.Repeat("a", 64)+`</title>"}`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	// an uploaded file is limited the same way
+	recorder = httptest.NewRecorder()
+	analyserObj.AnalyseHTML(recorder, multipartForTest(t, nil, "<title>"+strings.Repeat("a", 64)+"</title>"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	analyserObj.AnalyseHTML(recorder, httptest.NewRequest(http.MethodPost, "/analyse/html",
+		strings.NewReader(`{"html": " "}`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,103 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	erro "github.com/web-page-analysis/server/error"
+	"github.com/web-page-analysis/service"
+	"net/http"
+)
+
+type Job struct {
+	// ctx is the server context, jobs outlive the request which created them
+	ctx       context.Context
+	container container.Container
+	config    bootstrap.Config
+}
+
+func NewJob(ctx context.Context, ctr container.Container, config bootstrap.Config) *Job {
+	return &Job{
+		ctx:       ctx,
+		container: ctr,
+		config:    config,
+	}
+}
+
+func (j Job) Create(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(j.ctx).Info("start to create analysis job")
+
+	// unmarshal the request
+	var analyserRequest domain.AnalyserRequest
+	err := json.NewDecoder(r.Body).Decode(&analyserRequest)
+	if err != nil {
+		log.Errorf("ERROR decoding request body, err: %+v", err)
+		erro.BadRequestError(fmt.Sprintf("ERROR decoding request body, err: %+v",
+			err), w)
+		return
+	}
+	runner := service.NewJobRunner(j.container, j.config)
+	job, statusCode, err := runner.Submit(j.ctx, analyserRequest)
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in creating the analysis job", statusCode, w)
+		return
+	}
+	writeJob(job, http.StatusAccepted, w)
+}
+
+func (j Job) Get(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	filter, err := parseLinkFilter(r)
+	if err != nil {
+		erro.BadRequestError(fmt.Sprintf("ERROR in query parameters, err: %+v",
+			err), w)
+		return
+	}
+	runner := service.NewJobRunner(j.container, j.config)
+	job, found := runner.Get(j.ctx, id, filter)
+	if !found {
+		jobNotFound(id, w)
+		return
+	}
+	writeJob(job, http.StatusOK, w)
+}
+
+func (j Job) Delete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	runner := service.NewJobRunner(j.container, j.config)
+	job, found := runner.Cancel(j.ctx, id)
+	if !found {
+		jobNotFound(id, w)
+		return
+	}
+	if job.Status != domain.JobStatusCancelled {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			errors.New("job already "+job.Status)), "job cannot be cancelled", http.StatusConflict, w)
+		return
+	}
+	writeJob(job, http.StatusOK, w)
+}
+
+func jobNotFound(id string, w http.ResponseWriter) {
+	erro.GeneralError(fmt.Sprintf("err: %+v",
+		errors.New("job not found: "+id)), "job not found", http.StatusNotFound, w)
+}
+
+func writeJob(job domain.Job, statusCode int, w http.ResponseWriter) {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in marshalling response", http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	w.Write(raw)
+}
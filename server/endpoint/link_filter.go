@@ -0,0 +1,59 @@
+package endpoint
+
+import (
+	"errors"
+	"fmt"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseLinkFilter reads the link paging and filtering query parameters,
+// it returns nil when none of them is given
+func parseLinkFilter(r *http.Request) (filter *domain.LinkFilter, err error) {
+	query := r.URL.Query()
+	present := false
+	for _, key := range []string{"page", "page_size", "status", "type", "host"} {
+		if query.Has(key) {
+			present = true
+		}
+	}
+	if !present {
+		return nil, nil
+	}
+
+	filter = &domain.LinkFilter{
+		StatusClass: strings.ToLower(query.Get("status")),
+		Type:        strings.ToLower(query.Get("type")),
+		Host:        query.Get("host"),
+	}
+	if filter.Page, err = positiveParam(query.Get("page")); err != nil {
+		return nil, fmt.Errorf("invalid page: %w", err)
+	}
+	if filter.PageSize, err = positiveParam(query.Get("page_size")); err != nil {
+		return nil, fmt.Errorf("invalid page_size: %w", err)
+	}
+	if !usecase.IsValidStatusClass(filter.StatusClass) {
+		return nil, errors.New("invalid status, expected one of 2xx, 3xx, 4xx, 5xx, error, inaccessible")
+	}
+	if !usecase.IsValidLinkType(filter.Type) {
+		return nil, errors.New("invalid type, expected internal, external or non_http")
+	}
+	return filter, nil
+}
+
+func positiveParam(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if number < 1 {
+		return 0, errors.New("must be greater than zero")
+	}
+	return number, nil
+}
@@ -0,0 +1,61 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	erro "github.com/web-page-analysis/server/error"
+	"github.com/web-page-analysis/service"
+	"net/http"
+)
+
+type Sitemap struct {
+	container container.Container
+	config    bootstrap.Config
+}
+
+func NewSitemap(ctr container.Container, config bootstrap.Config) *Sitemap {
+	return &Sitemap{
+		container: ctr,
+		config:    config,
+	}
+}
+
+func (s Sitemap) Analyse(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log.WithContext(ctx).Info("start to analyse the sitemap")
+
+	// unmarshal the request
+	var sitemapRequest domain.SitemapRequest
+	err := json.NewDecoder(r.Body).Decode(&sitemapRequest)
+	if err != nil {
+		log.Errorf("ERROR decoding request body, err: %+v", err)
+		erro.BadRequestError(fmt.Sprintf("ERROR decoding request body, err: %+v",
+			err), w)
+		return
+	}
+	sitemap := service.NewSitemap(s.container, s.config)
+	result, statusCode, err := sitemap.Analyse(ctx, sitemapRequest)
+	if errors.Is(err, container.ErrDisallowedAddress) {
+		erro.DestinationNotAllowedError(fmt.Sprintf("err: %+v", err), w)
+		return
+	}
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in analysing the sitemap", statusCode, w)
+		return
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			err), "error in marshalling response", http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(raw)
+	return
+}
@@ -0,0 +1,142 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	erro "github.com/web-page-analysis/server/error"
+	"github.com/web-page-analysis/service"
+	"github.com/web-page-analysis/usecase"
+	"net/http"
+	"strings"
+)
+
+const (
+	eventPhase  = "phase"
+	eventLink   = "link"
+	eventResult = "result"
+	eventError  = "error"
+)
+
+type Stream struct {
+	container container.Container
+	config    bootstrap.Config
+}
+
+type streamEvent struct {
+	name string
+	data interface{}
+}
+
+// streamProgress forwards the analysis progress to the response writer loop
+type streamProgress struct {
+	ctx    context.Context
+	events chan streamEvent
+}
+
+func NewStream(ctr container.Container, config bootstrap.Config) *Stream {
+	return &Stream{
+		container: ctr,
+		config:    config,
+	}
+}
+
+// Analyse runs the analysis of the url given in the query
+// and streams the progress as server-sent events
+func (s Stream) Analyse(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log.WithContext(ctx).Info("start to stream the analysis of the web page")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		erro.GeneralError(fmt.Sprintf("err: %+v",
+			errors.New("streaming unsupported")), "error in streaming the analysis", http.StatusInternalServerError, w)
+		return
+	}
+	analyserRequest := domain.AnalyserRequest{
+		Url:                r.URL.Query().Get("url"),
+		IncludeLinkDetails: r.URL.Query().Get("include_link_details") == "true",
+		BypassCache:        r.URL.Query().Get("bypass_cache") == "true",
+	}
+	if analysers := r.URL.Query().Get("analysers"); analysers != "" {
+		analyserRequest.Analysers = strings.Split(analysers, ",")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	progress := streamProgress{ctx: ctx, events: make(chan streamEvent, 64)}
+	done := make(chan streamEvent, 1)
+	go func() {
+		analyser := service.NewAnalyser(s.container, s.config)
+		result, statusCode, err := analyser.WebAnalyser(usecase.WithProgressListener(ctx, progress), analyserRequest)
+		if err != nil {
+			msg := erro.Msg{
+				Message:          fmt.Sprintf("err: %+v", err),
+				DeveloperMessage: "error in analysing the webpage",
+				Code:             statusCode,
+			}
+			if errors.Is(err, container.ErrDisallowedAddress) {
+				msg.ErrorCode = erro.CodeDestinationNotAllowed
+			}
+			done <- streamEvent{name: eventError, data: msg}
+			return
+		}
+		done <- streamEvent{name: eventResult, data: result}
+	}()
+
+	for {
+		select {
+		case event := <-progress.events:
+			writeEvent(event, w)
+			flusher.Flush()
+		case event := <-done:
+			// drain what was reported before the analysis finished
+			for len(progress.events) > 0 {
+				writeEvent(<-progress.events, w)
+			}
+			writeEvent(event, w)
+			flusher.Flush()
+			return
+		case <-ctx.Done():
+			log.WithContext(ctx).Info("client closed the analysis stream")
+			return
+		}
+	}
+}
+
+func writeEvent(event streamEvent, w http.ResponseWriter) {
+	raw, err := json.Marshal(event.data)
+	if err != nil {
+		log.Errorf("ERROR in marshalling event, err: %+v", err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, raw)
+}
+
+func (p streamProgress) PhaseCompleted(phase string, value interface{}) {
+	p.send(streamEvent{name: eventPhase, data: domain.PhaseEvent{Phase: phase, Value: value}})
+}
+
+func (p streamProgress) LinkFound(url string) {}
+
+func (p streamProgress) LinkChecked(check domain.LinkCheck) {
+	p.send(streamEvent{name: eventLink, data: check})
+}
+
+// send blocks until the writer loop takes the event,
+// unless the client has gone away
+func (p streamProgress) send(event streamEvent) {
+	select {
+	case p.events <- event:
+	case <-p.ctx.Done():
+	}
+}
@@ -3,13 +3,19 @@ package error
 import (
 	"encoding/json"
 	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
 	"net/http"
 )
 
+const (
+	CodeDestinationNotAllowed = domain.ErrorTypeDestinationNotAllowed
+)
+
 type Msg struct {
 	Message          string `json:"message"`
 	DeveloperMessage string `json:"developer_message"`
 	Code             int64  `json:"code"`
+	ErrorCode        string `json:"error_code,omitempty"`
 }
 
 type ErrorMsg struct {
@@ -52,3 +58,24 @@ func GeneralError(message, developerMessage string, code int64, w http.ResponseW
 	w.WriteHeader(int(code))
 	w.Write(data)
 }
+
+// DestinationNotAllowedError is returned when the url resolves to an address
+// blocked by the outbound address guard
+func DestinationNotAllowedError(developerMessage string, w http.ResponseWriter) {
+
+	errorMessage := Msg{
+		Message:          "destination not allowed",
+		DeveloperMessage: developerMessage,
+		Code:             http.StatusForbidden,
+		ErrorCode:        CodeDestinationNotAllowed,
+	}
+
+	errMsg := ErrorMsg{Error: errorMessage}
+	data, err := json.Marshal(errMsg)
+	if err != nil {
+		log.Errorf("ERROR in marshalling message, err: %+v", err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(data)
+}
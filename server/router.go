@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -9,15 +10,35 @@ import (
 	"github.com/web-page-analysis/container"
 	"github.com/web-page-analysis/server/endpoint"
 	"github.com/web-page-analysis/server/middleware"
+	"net"
 	"net/http"
 	"time"
 )
 
-func InitRouter(ctx context.Context, conf bootstrap.Config, ctr container.Container) {
+const (
+	shutdownTimeout = 15 * time.Second
+)
+
+// InitRouter serves until the ctx is done, the returned channel is closed
+// once the server has shut down
+func InitRouter(ctx context.Context, conf bootstrap.Config, ctr container.Container) <-chan struct{} {
 	r := mux.NewRouter()
 
 	analyserObj := endpoint.NewAnalyser(ctr, conf)
 	r.HandleFunc("/analyse", analyserObj.Analyse).Methods(http.MethodPost)
+	r.HandleFunc("/analyse/html", analyserObj.AnalyseHTML).Methods(http.MethodPost)
+	streamObj := endpoint.NewStream(ctr, conf)
+	r.HandleFunc("/analyse/stream", streamObj.Analyse).Methods(http.MethodGet)
+	crawlerObj := endpoint.NewCrawler(ctr, conf)
+	r.HandleFunc("/crawl", crawlerObj.Crawl).Methods(http.MethodPost)
+	sitemapObj := endpoint.NewSitemap(ctr, conf)
+	r.HandleFunc("/sitemap", sitemapObj.Analyse).Methods(http.MethodPost)
+	batchObj := endpoint.NewBatch(ctx, ctr, conf)
+	r.HandleFunc("/batch", batchObj.Analyse).Methods(http.MethodPost)
+	jobObj := endpoint.NewJob(ctx, ctr, conf)
+	r.HandleFunc("/jobs", jobObj.Create).Methods(http.MethodPost)
+	r.HandleFunc("/jobs/{id}", jobObj.Get).Methods(http.MethodGet)
+	r.HandleFunc("/jobs/{id}", jobObj.Delete).Methods(http.MethodDelete)
 	corsHandler := middleware.CorsMiddleware(r)
 	server := &http.Server{
 		Addr:         fmt.Sprintf("%v:%v", "0.0.0.0", conf.AppConfig.Port),
@@ -25,11 +46,28 @@ func InitRouter(ctx context.Context, conf bootstrap.Config, ctr container.Contai
 		ReadTimeout:  time.Second * 350,
 		IdleTimeout:  time.Second * 600,
 		Handler:      corsHandler,
+		// the requests are cancelled with the server ctx, so a shutdown stops
+		// their outbound calls and they answer with what is done
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
 	}
 	go func() {
 		err := server.ListenAndServe()
-		if err != nil {
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.WithContext(ctx).Fatalf("http server error: %+v", err)
 		}
 	}()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.WithContext(shutdownCtx).Errorf("http server shutdown error: %+v", err)
+		}
+	}()
+	return stopped
 }
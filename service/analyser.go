@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -22,6 +23,7 @@ const (
 
 type Analyser interface {
 	WebAnalyser(ctx context.Context, req domain.AnalyserRequest) (res domain.AnalysisResult, errorCode int64, err error)
+	HTMLAnalyser(ctx context.Context, req domain.HTMLAnalyserRequest) (res domain.AnalysisResult, errorCode int64, err error)
 }
 
 type analyser struct {
@@ -45,28 +47,48 @@ func (a analyser) WebAnalyser(ctx context.Context, req domain.AnalyserRequest) (
 		log.WithContext(ctx).Error(prefix, "Invalid url")
 		return res, http.StatusBadRequest, errors.New("invalid url")
 	}
+	if err = firstPageOnly(req.LinkFilter); err != nil {
+		log.WithContext(ctx).Error(prefix, "Page past the first asked for")
+		return res, http.StatusBadRequest, err
+	}
 
-	// start analysing the webpage
-	analyserObj := usecase.NewAnalyser(a.container, a.config)
+	// pick the analysers before the page is fetched, an unknown name is a bad request
+	analysers, err := usecase.NewDefaultRegistry(a.container, a.config).Select(req.Analysers)
+	if err != nil {
+		log.WithContext(ctx).Error(prefix, "Invalid analysers, err: ", err)
+		return res, http.StatusBadRequest, err
+	}
+	ctx, cancel := a.analysisContext(ctx, req.BypassCache, req.Timeout)
+	defer cancel()
 
 	// call the webpage to get the html
-	resp, err := a.container.OBAdapter.Get(ctx, req.Url)
+	resp, verifyErr, err := a.fetchPage(ctx, req.Url, analysers)
 	if err != nil {
 		log.WithContext(ctx).Error(prefix, "Error in calling outbound call, err: ", err)
-		return res, http.StatusInternalServerError, err
+		if errors.Is(err, container.ErrDisallowedAddress) {
+			return res, http.StatusForbidden, err
+		}
+		return res, errorStatus(err), err
 	}
 	if resp != nil && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		// a redirect chain stopped on a loop or on too many hops is what is reported for the page
+		if chain := usecase.NewRedirect().Chain(ctx, resp); chain != nil && (chain.Loop || chain.TooManyHops) {
+			res.Redirect = chain
+			res.Findings = usecase.RedirectFindings(chain)
+			return res, http.StatusOK, nil
+		}
 		log.WithContext(ctx).Error(prefix, "Error in calling outbound call, status: ", resp.StatusCode)
 		return res, int64(resp.StatusCode), errors.New(fmt.Sprintf("Error in reaching server,  status: %s", resp.Status))
 	}
 
-	// close the resp body in need to close the file descriptor in resource level
-	defer resp.Body.Close()
-
 	bodyBytes, err := io.ReadAll(resp.Body)
+	// close the resp body in need to close the file descriptor in resource level,
+	// it is closed before the links are checked as it also holds a slot on the host
+	resp.Body.Close()
 	if err != nil {
 		log.WithContext(ctx).Error(prefix, "Error in reading response body, err: ", err)
-		return res, http.StatusInternalServerError, err
+		return res, errorStatus(err), err
 	}
 	bodyString := string(bodyBytes)
 	// need to read the resp.Body twice, to overcome this,used this technique
@@ -78,66 +100,198 @@ func (a analyser) WebAnalyser(ctx context.Context, req domain.AnalyserRequest) (
 		return res, http.StatusInternalServerError, err
 	}
 
-	wg := new(sync.WaitGroup)
-	var (
-		title       string
-		htmlVersion string
-		login       bool
-		link        domain.Link
-		heading     map[string]int
-	)
-
-	// get the title of the html
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		title = analyserObj.GetTitle(ctx, doc)
-		return
-	}()
-
-	// get the html version of the html
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		htmlVersion = analyserObj.CheckHtmlVersion(ctx, bodyString)
-		return
-	}()
-
-	// check any logins are there in the html
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		login = analyserObj.CheckAnyLogin(ctx, doc)
-		return
-
-	}()
-
-	// check any links are there in the html
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		link = analyserObj.CountLinks(ctx, doc, req.Url)
-		return
-
-	}()
-
-	// count the heading types in the html
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		heading = analyserObj.CountHeading(ctx, doc)
-		return
-	}()
+	// relative links are resolved against the url the page was served from
+	pageURL := req.Url
+	if resp.Request != nil && resp.Request.URL != nil {
+		pageURL = resp.Request.URL.String()
+	}
+
+	input := usecase.PageInput{
+		Doc:     doc,
+		RawHTML: bodyString,
+		Response: usecase.PageResponse{
+			Url:        pageURL,
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			TLS:        resp.TLS,
+			Redirect:   usecase.NewRedirect().Chain(ctx, resp),
+		},
+		Request: req,
+	}
+
+	res = a.analysePage(ctx, analysers, input)
+	if verifyErr != nil {
+		res.TLS.Trusted = false
+		res.TLS.Findings = append(res.TLS.Findings, usecase.VerificationFinding(verifyErr))
+	}
+	return res, http.StatusOK, nil
+
+}
+
+// fetchPage gets the page with its certificate verified. Only when the tls analyser
+// is selected, a page failing the verification is fetched again without it so its
+// certificate can be reported, the verification error is returned along with it
+func (a analyser) fetchPage(ctx context.Context, url string, analysers []usecase.PageAnalyser) (resp *http.Response, verifyErr error, err error) {
+	resp, err = a.container.OBAdapter.Get(ctx, url)
+	if err == nil || !usecase.IsCertificateError(err) || !selected(analysers, usecase.AnalyserTLS) {
+		return resp, nil, err
+	}
+	log.WithContext(ctx).Error(prefix, "Certificate verification failed, fetching without it, err: ", err)
+	resp, retryErr := a.container.OBAdapter.GetUnverified(ctx, url)
+	if retryErr != nil {
+		return nil, nil, retryErr
+	}
+	return resp, err, nil
+}
+
+func selected(analysers []usecase.PageAnalyser, name string) bool {
+	for _, pageAnalyser := range analysers {
+		if pageAnalyser.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HTMLAnalyser runs the analysers on the html given in the request, nothing is fetched
+// for the page itself, the links are still checked when a base url is given
+func (a analyser) HTMLAnalyser(ctx context.Context, req domain.HTMLAnalyserRequest) (res domain.AnalysisResult, errorCode int64, err error) {
+	log.WithContext(ctx).Info(prefix, "start to analyse the supplied html")
+	if strings.TrimSpace(req.HTML) == "" {
+		log.WithContext(ctx).Error(prefix, "Empty html")
+		return res, http.StatusBadRequest, errors.New("html is empty")
+	}
+	if req.BaseUrl != "" && !usecase.NewValidation().IsValidUrl(ctx, req.BaseUrl) {
+		log.WithContext(ctx).Error(prefix, "Invalid base url")
+		return res, http.StatusBadRequest, errors.New("invalid base url")
+	}
+	if err = firstPageOnly(req.LinkFilter); err != nil {
+		log.WithContext(ctx).Error(prefix, "Page past the first asked for")
+		return res, http.StatusBadRequest, err
+	}
+
+	analysers, err := usecase.SelectForHTML(usecase.NewDefaultRegistry(a.container, a.config),
+		req.Analysers, req.BaseUrl != "")
+	if err != nil {
+		log.WithContext(ctx).Error(prefix, "Invalid analysers, err: ", err)
+		return res, http.StatusBadRequest, err
+	}
+	ctx, cancel := a.analysisContext(ctx, req.BypassCache, req.Timeout)
+	defer cancel()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(req.HTML))
+	if err != nil {
+		log.WithContext(ctx).Error(prefix, "Data cannot be parsed to HTML, err: ", err)
+		return res, http.StatusBadRequest, err
+	}
+
+	input := usecase.PageInput{
+		Doc:     doc,
+		RawHTML: req.HTML,
+		Response: usecase.PageResponse{
+			Url: req.BaseUrl,
+		},
+		Request: domain.AnalyserRequest{
+			Url:                req.BaseUrl,
+			IncludeLinkDetails: req.IncludeLinkDetails,
+			LinkFilter:         req.LinkFilter,
+			Analysers:          req.Analysers,
+			BypassCache:        req.BypassCache,
+			Timeout:            req.Timeout,
+		},
+	}
+	return a.analysePage(ctx, analysers, input), http.StatusOK, nil
+}
 
+// analysisContext applies the cache bypass and the deadline of the request,
+// the deadline is capped by analysis_timeout
+func (a analyser) analysisContext(ctx context.Context, bypassCache bool, timeout int) (context.Context, context.CancelFunc) {
+	if bypassCache {
+		ctx = usecase.WithoutLinkCache(ctx)
+	}
+	if timeout = limit(timeout, int(a.config.AppConfig.AnalysisTimeout)); timeout > 0 {
+		return context.WithTimeout(ctx, time.Millisecond*time.Duration(timeout))
+	}
+	return context.WithCancel(ctx)
+}
+
+// analysePage runs the selected analysers side by side on the page and puts their sections together
+func (a analyser) analysePage(ctx context.Context, analysers []usecase.PageAnalyser, input usecase.PageInput) domain.AnalysisResult {
+	// each analyser reports its phase when done
+	progress := usecase.Progress(ctx)
+	sections := make([]usecase.Section, len(analysers))
+	wg := new(sync.WaitGroup)
+	for i, pageAnalyser := range analysers {
+		wg.Add(1)
+		go func(i int, pageAnalyser usecase.PageAnalyser) {
+			defer wg.Done()
+			sections[i] = pageAnalyser.Analyse(ctx, input)
+			progress.PhaseCompleted(pageAnalyser.Name(), sections[i].Value)
+		}(i, pageAnalyser)
+	}
 	wg.Wait()
+
+	// the sections are complete up to where the deadline or the caller stopped them
 	result := domain.AnalysisResult{
-		HTMLVersion:  htmlVersion,
-		Title:        title,
-		Headings:     heading,
-		Link:         link,
-		HasLoginForm: login,
+		Redirect:  input.Response.Redirect,
+		Truncated: ctx.Err() != nil,
+	}
+	if result.Truncated {
+		log.WithContext(ctx).Error(prefix, "analysis truncated, err: ", ctx.Err())
 	}
+	for _, section := range sections {
+		section.Apply(&result)
+	}
+	return a.present(ctx, result, input.Request)
+}
 
-	return result, http.StatusOK, nil
+// firstPageOnly refuses a page past the first, those are read from the result
+// stored with the first one so the page is not analysed again for each of them
+func firstPageOnly(filter *domain.LinkFilter) error {
+	if filter != nil && filter.Page > 1 {
+		return errors.New("the next pages are read from /jobs/{job_id} of the first page")
+	}
+	return nil
+}
 
+// present pages through the link records when a filter is given, the whole result
+// is then kept as a completed job so the next pages are read from it, not analysed again
+func (a analyser) present(ctx context.Context, result domain.AnalysisResult, req domain.AnalyserRequest) domain.AnalysisResult {
+	if req.LinkFilter != nil && a.container.JobStore != nil {
+		stored, full := req, result
+		stored.LinkFilter = nil
+		job, err := a.container.JobStore.Create(domain.Job{
+			Kind:    domain.JobKindAnalysis,
+			Status:  domain.JobStatusCompleted,
+			Request: &stored,
+			Result:  &full,
+		}, nil)
+		if err != nil {
+			log.WithContext(ctx).Error(prefix, "Result not stored for paging, err: ", err)
+		}
+		result.JobId = job.Id
+	}
+	result.Link = presentLinks(ctx, result.Link, req)
+	return result
+}
+
+// errorStatus tells a page which could not be fetched in time apart from the other failures
+func errorStatus(err error) int64 {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// presentLinks pages through the link records when a filter is given.
+// Otherwise the records are only sent when asked for,
+// to keep the response of the existing clients unchanged
+func presentLinks(ctx context.Context, link domain.Link, req domain.AnalyserRequest) domain.Link {
+	switch {
+	case req.LinkFilter != nil:
+		return usecase.NewLinkPagination().Paginate(ctx, link, *req.LinkFilter)
+	case !req.IncludeLinkDetails:
+		link.Details = nil
+	}
+	return link
 }
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func configForTest() bootstrap.Config {
+	return bootstrap.Config{
+		AppConfig: bootstrap.AppConfig{
+			WorkerCount:     4,
+			CrawlMaxDepth:   3,
+			CrawlMaxPages:   50,
+			JobConcurrency:  1,
+			AnalysisTimeout: 5000,
+		},
+		OutboundConf: bootstrap.OutboundConfig{DialTimeout: 1000},
+	}
+}
+
+func containerForTest(conf bootstrap.Config) container.Container {
+	return container.Container{
+		OBAdapter: container.InitOutBoundConnection(conf),
+		JobStore:  container.InitJobStore(conf),
+	}
+}
+
+// siteForTest serves the pages by path, a path it does not know is answered 404
+func siteForTest(t *testing.T, pages map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebAnalyserFreesTheHostBeforeCheckingLinks(t *testing.T) {
+	server := siteForTest(t, map[string]string{
+		"/":  `<html><head><title>Home</title></head><body><a href="/a">a</a><a href="/b">b</a></body></html>`,
+		"/a": `a`,
+		"/b": `b`,
+	})
+	conf := configForTest()
+	// the page and its links share the single slot of the host
+	conf.OutboundConf.HostLimits.MaxConcurrency = 1
+	conf.AppConfig.AnalysisTimeout = 2000
+	analyserObj := NewAnalyser(containerForTest(conf), conf)
+
+	result, statusCode, err := analyserObj.WebAnalyser(context.Background(), domain.AnalyserRequest{
+		Url:       server.URL,
+		Analysers: []string{"links"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(http.StatusOK), statusCode)
+	assert.False(t, result.Truncated)
+	assert.Equal(t, 2, result.Link.InternalLinks)
+	assert.Equal(t, 0, result.Link.InaccessibleLinkCount)
+}
+
+func TestWebAnalyserVerifiesTheCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Home</title></head></html>`)
+	}))
+	defer server.Close()
+	conf := configForTest()
+	analyserObj := NewAnalyser(containerForTest(conf), conf)
+
+	// without the tls analyser a self-signed page is not analysed
+	_, _, err := analyserObj.WebAnalyser(context.Background(), domain.AnalyserRequest{
+		Url:       server.URL,
+		Analysers: []string{"title"},
+	})
+	assert.Error(t, err)
+
+	// with it the page is analysed and the certificate reported
+	result, _, err := analyserObj.WebAnalyser(context.Background(), domain.AnalyserRequest{
+		Url:       server.URL,
+		Analysers: []string{"title", "tls"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Home", result.Title)
+	assert.False(t, result.TLS.Trusted)
+	codes := make([]string, 0)
+	for _, finding := range result.TLS.Findings {
+		codes = append(codes, finding.Code)
+	}
+	assert.Contains(t, codes, "tls_verification_failed")
+}
+
+func TestWebAnalyserReportsARedirectLoop(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusFound))
+	mux.Handle("/b", http.RedirectHandler("/a", http.StatusFound))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	conf := configForTest()
+	analyserObj := NewAnalyser(containerForTest(conf), conf)
+
+	result, statusCode, err := analyserObj.WebAnalyser(context.Background(), domain.AnalyserRequest{Url: server.URL + "/a"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(http.StatusOK), statusCode)
+	if assert.NotNil(t, result.Redirect) {
+		assert.True(t, result.Redirect.Loop)
+		assert.Len(t, result.Redirect.Hops, 2)
+	}
+	if assert.Len(t, result.Findings, 1) {
+		assert.Equal(t, "redirect_loop", result.Findings[0].Code)
+	}
+}
+
+func TestHTMLAnalyserWithoutBaseUrl(t *testing.T) {
+	conf := configForTest()
+	analyserObj := NewAnalyser(containerForTest(conf), conf)
+	var (
+		htmlForTest = `<!DOCTYPE html><html><head><title>Offline</title></head>` +
+			`<body><h1>Page</h1><a href="/a">a</a><form><input type="password"></form></body></html>`
+	)
+
+	// the links are not checked, there is nothing to resolve them against
+	result, statusCode, err := analyserObj.HTMLAnalyser(context.Background(), domain.HTMLAnalyserRequest{
+		HTML:      htmlForTest,
+		Analysers: []string{"title", "html_version", "login", "headings"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(http.StatusOK), statusCode)
+	assert.Equal(t, "Offline", result.Title)
+	assert.Equal(t, "HTML5", result.HTMLVersion)
+	assert.True(t, result.HasLoginForm)
+	assert.Equal(t, 1, result.Headings["h1"])
+	assert.Equal(t, 0, result.Link.InternalLinks)
+
+	_, statusCode, err = analyserObj.HTMLAnalyser(context.Background(), domain.HTMLAnalyserRequest{
+		HTML:      htmlForTest,
+		Analysers: []string{"links"},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+
+	_, statusCode, err = analyserObj.HTMLAnalyser(context.Background(), domain.HTMLAnalyserRequest{HTML: " "})
+	assert.EqualError(t, err, "html is empty")
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+}
+
+func TestHTMLAnalyserWithBaseUrl(t *testing.T) {
+	server := siteForTest(t, map[string]string{
+		"/docs/a": `a`,
+	})
+	conf := configForTest()
+	analyserObj := NewAnalyser(containerForTest(conf), conf)
+
+	// the relative links are resolved against the base url and checked
+	result, statusCode, err := analyserObj.HTMLAnalyser(context.Background(), domain.HTMLAnalyserRequest{
+		HTML:               `<html><head><title>Staging</title></head><body><a href="a">a</a><a href="/b">b</a></body></html>`,
+		BaseUrl:            server.URL + "/docs/",
+		Analysers:          []string{"title", "links"},
+		IncludeLinkDetails: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(http.StatusOK), statusCode)
+	assert.Equal(t, "Staging", result.Title)
+	assert.Equal(t, 2, result.Link.InternalLinks)
+	assert.Equal(t, []string{server.URL + "/b"}, result.Link.InaccessibleLink)
+	if assert.Len(t, result.Link.Details, 2) {
+		assert.Equal(t, server.URL+"/docs/a", result.Link.Details[0].Url)
+	}
+
+	_, statusCode, err = analyserObj.HTMLAnalyser(context.Background(), domain.HTMLAnalyserRequest{
+		HTML:    `<title>Staging</title>`,
+		BaseUrl: "not a url",
+	})
+	assert.EqualError(t, err, "invalid base url")
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+}
+
+func TestWebAnalyserStoresAPagedResult(t *testing.T) {
+	var pageRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			atomic.AddInt32(&pageRequests, 1)
+			fmt.Fprint(w, `<html><body><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a></body></html>`)
+			return
+		}
+		if r.URL.Path == "/c" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	conf := configForTest()
+	ctr := containerForTest(conf)
+	ctx := context.Background()
+
+	result, _, err := NewAnalyser(ctr, conf).WebAnalyser(ctx, domain.AnalyserRequest{
+		Url:        server.URL,
+		Analysers:  []string{"links"},
+		LinkFilter: &domain.LinkFilter{PageSize: 2},
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.JobId)
+	assert.Len(t, result.Link.Details, 2)
+	assert.Equal(t, 2, result.Link.Pagination.TotalPages)
+	// the inaccessible links of the first page agree with their count, the total is in the pagination
+	assert.Empty(t, result.Link.InaccessibleLink)
+	assert.Equal(t, 0, result.Link.InaccessibleLinkCount)
+	assert.Equal(t, 1, result.Link.Pagination.TotalInaccessible)
+
+	// the next page is read from the stored result, the page is not analysed again
+	job, found := NewJobRunner(ctr, conf).Get(ctx, result.JobId, &domain.LinkFilter{Page: 2, PageSize: 2})
+	assert.True(t, found)
+	assert.Equal(t, domain.JobStatusCompleted, job.Status)
+	if assert.Len(t, job.Result.Link.Details, 1) {
+		assert.Equal(t, server.URL+"/c", job.Result.Link.Details[0].Url)
+	}
+	assert.Equal(t, []string{server.URL + "/c"}, job.Result.Link.InaccessibleLink)
+	assert.Equal(t, 1, job.Result.Link.InaccessibleLinkCount)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&pageRequests))
+
+	// the next pages are not analysed again
+	_, statusCode, err := NewAnalyser(ctr, conf).WebAnalyser(ctx, domain.AnalyserRequest{
+		Url:        server.URL,
+		Analysers:  []string{"links"},
+		LinkFilter: &domain.LinkFilter{Page: 2, PageSize: 2},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&pageRequests))
+
+	// without a filter nothing is stored
+	result, _, err = NewAnalyser(ctr, conf).WebAnalyser(ctx, domain.AnalyserRequest{Url: server.URL, Analysers: []string{"links"}})
+	assert.NoError(t, err)
+	assert.Empty(t, result.JobId)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/usecase"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	batchPrefix = "service.batch "
+
+	defaultBatchConcurrency = 4
+)
+
+type Batch interface {
+	Analyse(ctx context.Context, req domain.BatchRequest) (res domain.BatchResult, errorCode int64, err error)
+	// Submit enqueues the batch as a job, it runs in the background with the given ctx as parent
+	Submit(ctx context.Context, req domain.BatchRequest) (job domain.Job, errorCode int64, err error)
+}
+
+type batch struct {
+	container container.Container
+	config    bootstrap.Config
+}
+
+func NewBatch(ctr container.Container, config bootstrap.Config) Batch {
+	return &batch{
+		container: ctr,
+		config:    config,
+	}
+}
+
+// Analyse runs the analysis of every url, batch_concurrency pages at a time,
+// the links of all the pages are checked by one pool of worker_count checks
+func (b batch) Analyse(ctx context.Context, req domain.BatchRequest) (res domain.BatchResult, errorCode int64, err error) {
+	log.WithContext(ctx).Info(batchPrefix, "start to analyse the batch")
+	urls, errorCode, err := b.validate(ctx, req)
+	if err != nil {
+		return res, errorCode, err
+	}
+	return b.analyse(ctx, urls, req, func() {}), http.StatusOK, nil
+}
+
+// Submit validates the batch before it is queued, the result is read from the job
+func (b batch) Submit(ctx context.Context, req domain.BatchRequest) (job domain.Job, errorCode int64, err error) {
+	log.WithContext(ctx).Info(batchPrefix, "submit batch job")
+	urls, errorCode, err := b.validate(ctx, req)
+	if err != nil {
+		return job, errorCode, err
+	}
+	req.Urls = urls
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	job, err = b.container.JobStore.Create(domain.Job{Kind: domain.JobKindBatch, BatchRequest: &req}, cancel)
+	if err != nil {
+		cancel()
+		log.WithContext(ctx).Error(batchPrefix, "Job not created, err: ", err)
+		return job, http.StatusServiceUnavailable, err
+	}
+	go b.run(jobCtx, cancel, job.Id, req)
+	return job, http.StatusAccepted, nil
+}
+
+func (b batch) run(ctx context.Context, cancel context.CancelFunc, id string, req domain.BatchRequest) {
+	defer cancel()
+	store := b.container.JobStore
+
+	// the batch takes one job slot, a job cancelled while queued never starts
+	err := store.Acquire(ctx)
+	if err != nil {
+		log.WithContext(ctx).Info(batchPrefix, "job cancelled before start ", id)
+		return
+	}
+	defer store.Release()
+
+	store.Update(id, func(job *domain.Job) {
+		job.Status = domain.JobStatusRunning
+		job.Progress.TotalPages = len(req.Urls)
+	})
+	res := b.analyse(ctx, req.Urls, req, func() {
+		store.Update(id, func(job *domain.Job) {
+			job.Progress.PagesDone++
+		})
+	})
+	store.Update(id, func(job *domain.Job) {
+		job.Status = domain.JobStatusCompleted
+		job.BatchResult = &res
+	})
+}
+
+// validate returns the distinct urls of the batch
+func (b batch) validate(ctx context.Context, req domain.BatchRequest) (urls []string, errorCode int64, err error) {
+	urls = distinctUrls(req.Urls)
+	if len(urls) == 0 {
+		log.WithContext(ctx).Error(batchPrefix, "Empty batch")
+		return nil, http.StatusBadRequest, errors.New("no urls given")
+	}
+	if maxUrls := int(b.config.AppConfig.BatchMaxUrls); maxUrls > 0 && len(urls) > maxUrls {
+		log.WithContext(ctx).Error(batchPrefix, "Too many urls: ", len(urls))
+		return nil, http.StatusBadRequest, fmt.Errorf("%d urls given, at most %d are accepted", len(urls), maxUrls)
+	}
+	if _, err = usecase.NewDefaultRegistry(b.container, b.config).Select(req.Analysers); err != nil {
+		log.WithContext(ctx).Error(batchPrefix, "Invalid analysers, err: ", err)
+		return nil, http.StatusBadRequest, err
+	}
+	return urls, http.StatusOK, nil
+}
+
+// analyse calls pageDone after each url
+func (b batch) analyse(ctx context.Context, urls []string, req domain.BatchRequest, pageDone func()) (res domain.BatchResult) {
+	concurrency := int(b.config.AppConfig.BatchConcurrency)
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	ctx = usecase.WithLinkCheckPool(ctx, int(b.config.AppConfig.WorkerCount))
+	analyserObj := NewAnalyser(b.container, b.config)
+
+	res.Results = make([]domain.BatchItem, len(urls))
+	indexes := make(chan int)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < concurrency && i < len(urls); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				res.Results[index] = b.analyseUrl(ctx, analyserObj, urls[index], req)
+				pageDone()
+			}
+		}()
+	}
+	for index := range urls {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	res.Summary = summariseBatch(res.Results)
+	return res
+}
+
+func (b batch) analyseUrl(ctx context.Context, analyserObj Analyser, url string, req domain.BatchRequest) domain.BatchItem {
+	item := domain.BatchItem{Url: url}
+	result, statusCode, err := analyserObj.WebAnalyser(ctx, domain.AnalyserRequest{
+		Url:         url,
+		Analysers:   req.Analysers,
+		BypassCache: req.BypassCache,
+		Timeout:     req.Timeout,
+	})
+	item.StatusCode = statusCode
+	if err != nil {
+		log.WithContext(ctx).Error(batchPrefix, "Error in analysing page: ", url, " err: ", err)
+		item.Error = err.Error()
+		return item
+	}
+	item.Result = &result
+	return item
+}
+
+func summariseBatch(items []domain.BatchItem) domain.BatchSummary {
+	summary := domain.BatchSummary{
+		TotalUrls: len(items),
+		Rows:      make([]domain.BatchRow, 0, len(items)),
+	}
+	for _, item := range items {
+		row := domain.BatchRow{
+			Url:        item.Url,
+			StatusCode: item.StatusCode,
+			Error:      item.Error,
+		}
+		if item.Result == nil {
+			summary.Failed++
+			summary.Rows = append(summary.Rows, row)
+			continue
+		}
+		summary.Succeeded++
+		row.Title = item.Result.Title
+		row.HTMLVersion = item.Result.HTMLVersion
+		row.InternalLinks = item.Result.Link.InternalLinks
+		row.ExternalLinks = item.Result.Link.ExternalLinks
+		row.InaccessibleLinks = item.Result.Link.InaccessibleLinkCount
+		row.HasLoginForm = item.Result.HasLoginForm
+		row.Truncated = item.Result.Truncated
+		summary.Rows = append(summary.Rows, row)
+	}
+	return summary
+}
+
+// distinctUrls drops the blank and the repeated urls, keeping the order
+func distinctUrls(urls []string) []string {
+	seen := make(map[string]interface{})
+	distinct := make([]string, 0, len(urls))
+	for _, url := range urls {
+		url = strings.TrimSpace(url)
+		if _, ok := seen[url]; ok || url == "" {
+			continue
+		}
+		seen[url] = nil
+		distinct = append(distinct, url)
+	}
+	return distinct
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"testing"
+)
+
+func TestBatchJob(t *testing.T) {
+	server := siteForTest(t, map[string]string{
+		"/one": `<html><head><title>One</title></head><body><a href="/two">two</a></body></html>`,
+		"/two": `<html><head><title>Two</title></head></html>`,
+	})
+	conf := configForTest()
+	conf.AppConfig.BatchMaxUrls = 3
+	ctr := containerForTest(conf)
+	batchObj := NewBatch(ctr, conf)
+	runner := NewJobRunner(ctr, conf)
+
+	job, statusCode, err := batchObj.Submit(context.Background(), domain.BatchRequest{
+		Urls:      []string{server.URL + "/one", " ", server.URL + "/two", server.URL + "/one", server.URL + "/missing"},
+		Analysers: []string{"title", "links"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(http.StatusAccepted), statusCode)
+	assert.Equal(t, domain.JobKindBatch, job.Kind)
+	assert.Len(t, job.BatchRequest.Urls, 3)
+
+	job = waitForJob(t, runner, job.Id, domain.JobStatusCompleted)
+	assert.Equal(t, 3, job.Progress.TotalPages)
+	assert.Equal(t, 3, job.Progress.PagesDone)
+	if assert.NotNil(t, job.BatchResult) {
+		assert.Equal(t, "One", job.BatchResult.Results[0].Result.Title)
+		assert.Equal(t, 1, job.BatchResult.Results[0].Result.Link.InternalLinks)
+		assert.Equal(t, int64(http.StatusNotFound), job.BatchResult.Results[2].StatusCode)
+		assert.Equal(t, 2, job.BatchResult.Summary.Succeeded)
+		assert.Equal(t, 1, job.BatchResult.Summary.Failed)
+	}
+}
+
+func TestBatchValidation(t *testing.T) {
+	conf := configForTest()
+	conf.AppConfig.BatchMaxUrls = 1
+	batchObj := NewBatch(containerForTest(conf), conf)
+	ctx := context.Background()
+
+	_, statusCode, err := batchObj.Submit(ctx, domain.BatchRequest{Urls: []string{"", " "}})
+	assert.EqualError(t, err, "no urls given")
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+
+	_, statusCode, err = batchObj.Submit(ctx, domain.BatchRequest{Urls: []string{"http://a.com", "http://b.com"}})
+	assert.EqualError(t, err, "2 urls given, at most 1 are accepted")
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+
+	_, statusCode, err = batchObj.Submit(ctx, domain.BatchRequest{Urls: []string{"http://a.com"}, Analysers: []string{"unknown"}})
+	assert.Error(t, err)
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+}
+
+func TestSummariseBatch(t *testing.T) {
+	items := []domain.BatchItem{
+		{
+			Url:        "http://a.com",
+			StatusCode: http.StatusOK,
+			Result: &domain.AnalysisResult{
+				Title:        "A",
+				HTMLVersion:  "HTML5",
+				HasLoginForm: true,
+				Truncated:    true,
+				Link:         domain.Link{InternalLinks: 3, ExternalLinks: 2, InaccessibleLinkCount: 1},
+			},
+		},
+		{Url: "not a url", StatusCode: http.StatusBadRequest, Error: "invalid url"},
+	}
+
+	actual := summariseBatch(items)
+	assert.Equal(t, 2, actual.TotalUrls)
+	assert.Equal(t, 1, actual.Succeeded)
+	assert.Equal(t, 1, actual.Failed)
+	assert.Equal(t, []domain.BatchRow{
+		{
+			Url:               "http://a.com",
+			StatusCode:        http.StatusOK,
+			Title:             "A",
+			HTMLVersion:       "HTML5",
+			InternalLinks:     3,
+			ExternalLinks:     2,
+			InaccessibleLinks: 1,
+			HasLoginForm:      true,
+			Truncated:         true,
+		},
+		{Url: "not a url", StatusCode: http.StatusBadRequest, Error: "invalid url"},
+	}, actual.Rows)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/usecase"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	crawlerPrefix = "service.crawler "
+)
+
+type Crawler interface {
+	Crawl(ctx context.Context, req domain.CrawlRequest) (res domain.CrawlResult, errorCode int64, err error)
+}
+
+type crawler struct {
+	container container.Container
+	config    bootstrap.Config
+}
+
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+func NewCrawler(ctr container.Container, config bootstrap.Config) Crawler {
+	return &crawler{
+		container: ctr,
+		config:    config,
+	}
+}
+
+// Crawl analyses the given url and follows the internal links found on
+// each page breadth first, until the depth or the page budget is reached
+func (c crawler) Crawl(ctx context.Context, req domain.CrawlRequest) (res domain.CrawlResult, errorCode int64, err error) {
+	log.WithContext(ctx).Info(crawlerPrefix, "start to crawl the site")
+	validatorObj := usecase.NewValidation()
+	if !validatorObj.IsValidUrl(ctx, req.Url) {
+		log.WithContext(ctx).Error(crawlerPrefix, "Invalid url")
+		return res, http.StatusBadRequest, errors.New("invalid url")
+	}
+	// the site is the host the start page is served from, after its redirects
+	siteURL := req.Url
+
+	maxDepth := limit(req.MaxDepth, int(c.config.AppConfig.CrawlMaxDepth))
+	maxPages := limit(req.MaxPages, int(c.config.AppConfig.CrawlMaxPages))
+	if maxPages < 1 {
+		maxPages = 1
+	}
+
+	// the internal links are needed to find the next pages,
+	// the title and the login form for the summary
+	analysers := req.Analysers
+	if len(analysers) > 0 {
+		analysers = append([]string{usecase.AnalyserLinks, usecase.AnalyserTitle, usecase.AnalyserLogin}, analysers...)
+	}
+
+	analyserObj := NewAnalyser(c.container, c.config)
+	visited := map[string]interface{}{crawlKey(req.Url): nil}
+	queue := []crawlItem{{url: req.Url}}
+	res.Pages = make([]domain.PageResult, 0)
+
+	// the crawl-delay of the site is honoured between the pages when robots.txt is respected
+	var crawlDelay time.Duration
+	if robotsConf := c.config.AppConfig.Robots; robotsConf.Enabled && robotsConf.Respect {
+		crawlDelay = usecase.NewRobots(c.container, c.config).CrawlDelay(ctx, req.Url)
+	}
+
+	for len(queue) > 0 && len(res.Pages) < maxPages {
+		item := queue[0]
+		queue = queue[1:]
+		// a crawl stopped while waiting keeps the pages analysed so far
+		if item.depth > 0 && !waitCrawlDelay(ctx, crawlDelay) || ctx.Err() != nil {
+			res.Truncated = true
+			break
+		}
+
+		page := domain.PageResult{Url: item.url, Depth: item.depth}
+		result, statusCode, err := analyserObj.WebAnalyser(ctx, domain.AnalyserRequest{
+			Url:         item.url,
+			Analysers:   analysers,
+			BypassCache: req.BypassCache,
+		})
+		if err != nil {
+			log.WithContext(ctx).Error(crawlerPrefix, "Error in analysing page: ", item.url, " err: ", err)
+			// nothing to crawl when the start page itself cannot be analysed
+			if item.depth == 0 {
+				return res, statusCode, err
+			}
+			// the page did not fail, the crawl was stopped while it was analysed
+			if ctx.Err() != nil {
+				res.Truncated = true
+				break
+			}
+			page.Error = err.Error()
+			res.Pages = append(res.Pages, page)
+			continue
+		}
+		page.Result = &result
+		res.Pages = append(res.Pages, page)
+		if result.Redirect != nil {
+			// the page a redirect led to is not analysed a second time
+			visited[crawlKey(result.Redirect.FinalUrl)] = nil
+			if item.depth == 0 {
+				siteURL = result.Redirect.FinalUrl
+			}
+		}
+
+		if item.depth >= maxDepth {
+			continue
+		}
+		for _, link := range result.Link.InternalLink {
+			if !usecase.IsInternalLink(siteURL, link, c.config.AppConfig.LinkClassification) {
+				continue
+			}
+			key := crawlKey(link)
+			if _, ok := visited[key]; ok {
+				continue
+			}
+			visited[key] = nil
+			queue = append(queue, crawlItem{url: link, depth: item.depth + 1})
+		}
+	}
+
+	res.Summary = summariseCrawl(res.Pages)
+	return res, http.StatusOK, nil
+}
+
+// waitCrawlDelay returns false when the ctx is done before the delay is over
+func waitCrawlDelay(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func summariseCrawl(pages []domain.PageResult) domain.CrawlSummary {
+	summary := domain.CrawlSummary{
+		TotalPages:         len(pages),
+		BrokenLinks:        make([]string, 0),
+		PagesWithoutTitle:  make([]string, 0),
+		PagesWithLoginForm: make([]string, 0),
+	}
+	brokenLinks := make(map[string]interface{})
+	for _, page := range pages {
+		if page.Result == nil {
+			summary.FailedPages++
+			continue
+		}
+		if strings.TrimSpace(page.Result.Title) == "" {
+			summary.PagesWithoutTitle = append(summary.PagesWithoutTitle, page.Url)
+		}
+		if page.Result.HasLoginForm {
+			summary.PagesWithLoginForm = append(summary.PagesWithLoginForm, page.Url)
+		}
+		for _, link := range page.Result.Link.InaccessibleLink {
+			if _, ok := brokenLinks[link]; ok {
+				continue
+			}
+			brokenLinks[link] = nil
+			summary.BrokenLinks = append(summary.BrokenLinks, link)
+		}
+	}
+	summary.BrokenLinkCount = len(summary.BrokenLinks)
+	return summary
+}
+
+// crawlKey drops the fragment and the trailing slash
+// so the same page is not analysed twice
+func crawlKey(rawURL string) string {
+	if i := strings.Index(rawURL, "#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	return strings.TrimSuffix(rawURL, "/")
+}
+
+// limit returns the requested value capped to the configured maximum,
+// falling back to the maximum when nothing was requested
+func limit(requested, max int) int {
+	if requested <= 0 || requested > max {
+		return max
+	}
+	return requested
+}
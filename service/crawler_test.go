@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCrawlSummaryWithSelectedAnalysers(t *testing.T) {
+	server := siteForTest(t, map[string]string{
+		"/":      `<html><head><title>Home</title></head><body><a href="/login">login</a></body></html>`,
+		"/login": `<html><head><title>Login</title></head><body><form><input type="password"></form></body></html>`,
+	})
+	conf := configForTest()
+	crawlerObj := NewCrawler(containerForTest(conf), conf)
+
+	result, _, err := crawlerObj.Crawl(context.Background(), domain.CrawlRequest{
+		Url:       server.URL,
+		Analysers: []string{"seo"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Summary.TotalPages)
+	assert.Empty(t, result.Summary.PagesWithoutTitle)
+	assert.Equal(t, []string{server.URL + "/login"}, result.Summary.PagesWithLoginForm)
+}
+
+func TestCrawlFollowsInternalLinksWithinBudgets(t *testing.T) {
+	server := siteForTest(t, map[string]string{
+		"/":  `<html><head><title>Home</title></head><body><a href="/a">a</a><a href="/b#top">b</a><a href="https://example.com/">out</a></body></html>`,
+		"/a": `<html><head><title>A</title></head><body><a href="/">home</a><a href="/c">c</a><a href="/missing">missing</a></body></html>`,
+		"/b": `<html><body><a href="/a/">a</a></body></html>`,
+		"/c": `<html><head><title>C</title></head><body><a href="/d">d</a></body></html>`,
+		"/d": `<html><head><title>D</title></head></body></html>`,
+	})
+	conf := configForTest()
+	crawlerObj := NewCrawler(containerForTest(conf), conf)
+	ctx := context.Background()
+
+	result, _, err := crawlerObj.Crawl(ctx, domain.CrawlRequest{Url: server.URL, MaxDepth: 2, Analysers: []string{"links"}})
+	assert.NoError(t, err)
+	pages := make(map[string]int)
+	for _, page := range result.Pages {
+		pages[page.Url] = page.Depth
+	}
+	// /d is three links away, the external link and the repeated pages are not followed
+	assert.Equal(t, map[string]int{
+		server.URL:              0,
+		server.URL + "/a":       1,
+		server.URL + "/b":       1,
+		server.URL + "/c":       2,
+		server.URL + "/missing": 2,
+	}, pages)
+	assert.Equal(t, 1, result.Summary.FailedPages)
+	assert.Equal(t, []string{server.URL + "/b"}, result.Summary.PagesWithoutTitle)
+	assert.Contains(t, result.Summary.BrokenLinks, server.URL+"/missing")
+	assert.False(t, result.Truncated)
+
+	result, _, err = crawlerObj.Crawl(ctx, domain.CrawlRequest{Url: server.URL, MaxDepth: 2, MaxPages: 2})
+	assert.NoError(t, err)
+	assert.Len(t, result.Pages, 2)
+
+	// the configured limits cap the request
+	conf.AppConfig.CrawlMaxDepth = 0
+	result, _, err = NewCrawler(containerForTest(conf), conf).Crawl(ctx, domain.CrawlRequest{Url: server.URL, MaxDepth: 5})
+	assert.NoError(t, err)
+	assert.Len(t, result.Pages, 1)
+}
+
+func TestCrawlFollowsTheRedirectedHost(t *testing.T) {
+	server := siteForTest(t, map[string]string{
+		"/home": `<html><head><title>Home</title></head><body><a href="/a">a</a><a href="/home">home</a></body></html>`,
+		"/a":    `<html><head><title>A</title></head></html>`,
+	})
+	// the site is entered on another host name which redirects to the server address
+	entry := httptest.NewServer(http.RedirectHandler(server.URL+"/home", http.StatusMovedPermanently))
+	defer entry.Close()
+	entryURL := strings.Replace(entry.URL, "127.0.0.1", "localhost", 1)
+	conf := configForTest()
+
+	result, _, err := NewCrawler(containerForTest(conf), conf).Crawl(context.Background(),
+		domain.CrawlRequest{Url: entryURL, MaxDepth: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Pages, 2) {
+		assert.Equal(t, server.URL+"/a", result.Pages[1].Url)
+	}
+}
+
+func TestCrawlFailsOnTheStartPage(t *testing.T) {
+	server := siteForTest(t, map[string]string{})
+	conf := configForTest()
+	crawlerObj := NewCrawler(containerForTest(conf), conf)
+
+	_, statusCode, err := crawlerObj.Crawl(context.Background(), domain.CrawlRequest{Url: server.URL})
+	assert.Error(t, err)
+	assert.Equal(t, int64(http.StatusNotFound), statusCode)
+
+	_, statusCode, err = crawlerObj.Crawl(context.Background(), domain.CrawlRequest{Url: "not a url"})
+	assert.Error(t, err)
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+}
+
+func TestSummariseCrawl(t *testing.T) {
+	summary := summariseCrawl([]domain.PageResult{
+		{Url: "http://abc.com/", Result: &domain.AnalysisResult{Title: "Home", HasLoginForm: true,
+			Link: domain.Link{InaccessibleLink: []string{"http://abc.com/x", "http://xyz.com/"}}}},
+		{Url: "http://abc.com/a", Result: &domain.AnalysisResult{Title: " ",
+			Link: domain.Link{InaccessibleLink: []string{"http://abc.com/x"}}}},
+		{Url: "http://abc.com/b", Error: "status 500"},
+	})
+	assert.Equal(t, domain.CrawlSummary{
+		TotalPages:         3,
+		FailedPages:        1,
+		BrokenLinkCount:    2,
+		BrokenLinks:        []string{"http://abc.com/x", "http://xyz.com/"},
+		PagesWithoutTitle:  []string{"http://abc.com/a"},
+		PagesWithLoginForm: []string{"http://abc.com/"},
+	}, summary)
+}
+
+func TestCrawlKey(t *testing.T) {
+	assert.Equal(t, "http://abc.com/a", crawlKey("http://abc.com/a/#top"))
+	assert.Equal(t, "http://abc.com", crawlKey("http://abc.com/"))
+	assert.Equal(t, "http://abc.com/a?b=1", crawlKey("http://abc.com/a?b=1"))
+}
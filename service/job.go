@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/usecase"
+	"net/http"
+)
+
+const (
+	jobPrefix = "service.job "
+)
+
+type JobRunner interface {
+	// Submit enqueues the analysis, it runs in the background with the given ctx as parent
+	Submit(ctx context.Context, req domain.AnalyserRequest) (job domain.Job, errorCode int64, err error)
+	// Get returns the job, the link records of the result are paged when a filter is given
+	Get(ctx context.Context, id string, filter *domain.LinkFilter) (job domain.Job, found bool)
+	Cancel(ctx context.Context, id string) (job domain.Job, found bool)
+}
+
+type jobRunner struct {
+	container container.Container
+	config    bootstrap.Config
+}
+
+// jobProgress records the analysis progress in the job store
+type jobProgress struct {
+	store container.JobStore
+	id    string
+}
+
+func NewJobRunner(ctr container.Container, config bootstrap.Config) JobRunner {
+	return &jobRunner{
+		container: ctr,
+		config:    config,
+	}
+}
+
+func (j jobRunner) Submit(ctx context.Context, req domain.AnalyserRequest) (job domain.Job, errorCode int64, err error) {
+	log.WithContext(ctx).Info(jobPrefix, "submit analysis job")
+	validatorObj := usecase.NewValidation()
+	if !validatorObj.IsValidUrl(ctx, req.Url) {
+		log.WithContext(ctx).Error(jobPrefix, "Invalid url")
+		return job, http.StatusBadRequest, errors.New("invalid url")
+	}
+	analysers, err := usecase.NewDefaultRegistry(j.container, j.config).Select(req.Analysers)
+	if err != nil {
+		log.WithContext(ctx).Error(jobPrefix, "Invalid analysers, err: ", err)
+		return job, http.StatusBadRequest, err
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	job, err = j.container.JobStore.Create(domain.Job{Kind: domain.JobKindAnalysis, Request: &req}, cancel)
+	if err != nil {
+		cancel()
+		log.WithContext(ctx).Error(jobPrefix, "Job not created, err: ", err)
+		return job, http.StatusServiceUnavailable, err
+	}
+	go j.run(jobCtx, cancel, job.Id, req, len(analysers))
+	return job, http.StatusAccepted, nil
+}
+
+func (j jobRunner) Get(ctx context.Context, id string, filter *domain.LinkFilter) (job domain.Job, found bool) {
+	job, found = j.container.JobStore.Get(id)
+	if !found || job.Result == nil || job.Request == nil {
+		return job, found
+	}
+	req := *job.Request
+	req.LinkFilter = filter
+	result := *job.Result
+	result.Link = presentLinks(ctx, result.Link, req)
+	job.Result = &result
+	return job, true
+}
+
+func (j jobRunner) Cancel(ctx context.Context, id string) (job domain.Job, found bool) {
+	log.WithContext(ctx).Info(jobPrefix, "cancel analysis job ", id)
+	return j.container.JobStore.Cancel(id)
+}
+
+func (j jobRunner) run(ctx context.Context, cancel context.CancelFunc, id string, req domain.AnalyserRequest, totalPhases int) {
+	defer cancel()
+	store := j.container.JobStore
+
+	// wait for a free slot, a job cancelled while queued never starts
+	err := store.Acquire(ctx)
+	if err != nil {
+		log.WithContext(ctx).Info(jobPrefix, "job cancelled before start ", id)
+		return
+	}
+	defer store.Release()
+
+	store.Update(id, func(job *domain.Job) {
+		job.Status = domain.JobStatusRunning
+		job.Progress.TotalPhases = totalPhases
+	})
+
+	// the link records are always kept, so the result can be paged through later
+	req.IncludeLinkDetails = true
+	req.LinkFilter = nil
+	ctx = usecase.WithProgressListener(ctx, jobProgress{store: store, id: id})
+	analyserObj := NewAnalyser(j.container, j.config)
+	result, statusCode, err := analyserObj.WebAnalyser(ctx, req)
+	store.Update(id, func(job *domain.Job) {
+		if err != nil {
+			log.WithContext(ctx).Error(jobPrefix, "job failed ", id, " err: ", err)
+			job.Status = domain.JobStatusFailed
+			job.Error = err.Error()
+			job.ErrorCode = statusCode
+			if errors.Is(err, container.ErrDisallowedAddress) {
+				job.ErrorType = domain.ErrorTypeDestinationNotAllowed
+			}
+			return
+		}
+		job.Status = domain.JobStatusCompleted
+		job.Result = &result
+	})
+}
+
+func (p jobProgress) PhaseCompleted(phase string, value interface{}) {
+	p.store.Update(p.id, func(job *domain.Job) {
+		job.Progress.CompletedPhases = append(job.Progress.CompletedPhases, phase)
+	})
+}
+
+func (p jobProgress) LinkFound(url string) {
+	p.store.Update(p.id, func(job *domain.Job) {
+		job.Progress.LinksFound++
+	})
+}
+
+func (p jobProgress) LinkChecked(check domain.LinkCheck) {
+	p.store.Update(p.id, func(job *domain.Job) {
+		job.Progress.LinksChecked++
+	})
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitForJob polls the job until its status is one of the given ones
+func waitForJob(t *testing.T, runner JobRunner, id string, statuses ...string) domain.Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, found := runner.Get(context.Background(), id, nil)
+		assert.True(t, found)
+		for _, status := range statuses {
+			if job.Status == status {
+				return job
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is still %s", id, job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobRunsToCompletion(t *testing.T) {
+	server := siteForTest(t, map[string]string{
+		"/":  `<html><head><title>Home</title></head><body><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a></body></html>`,
+		"/a": `a`,
+		"/b": `b`,
+	})
+	conf := configForTest()
+	runner := NewJobRunner(containerForTest(conf), conf)
+
+	job, statusCode, err := runner.Submit(context.Background(), domain.AnalyserRequest{
+		Url:       server.URL,
+		Analysers: []string{"title", "links"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(http.StatusAccepted), statusCode)
+	assert.Equal(t, domain.JobStatusQueued, job.Status)
+
+	job = waitForJob(t, runner, job.Id, domain.JobStatusCompleted, domain.JobStatusFailed)
+	assert.Equal(t, domain.JobStatusCompleted, job.Status)
+	assert.Equal(t, "Home", job.Result.Title)
+	assert.Equal(t, 2, job.Progress.TotalPhases)
+	assert.ElementsMatch(t, []string{"title", "links"}, job.Progress.CompletedPhases)
+	assert.Equal(t, 3, job.Progress.LinksFound)
+	assert.Equal(t, 3, job.Progress.LinksChecked)
+	// the link records are kept for paging, though not asked for
+	assert.Empty(t, job.Result.Link.Details)
+	job, _ = runner.Get(context.Background(), job.Id, &domain.LinkFilter{PageSize: 2, StatusClass: domain.StatusClassInaccessible})
+	assert.Len(t, job.Result.Link.Details, 1)
+	assert.Equal(t, server.URL+"/c", job.Result.Link.Details[0].Url)
+
+	_, found := runner.Get(context.Background(), "unknown", nil)
+	assert.False(t, found)
+}
+
+func TestJobFailure(t *testing.T) {
+	server := siteForTest(t, map[string]string{})
+	conf := configForTest()
+	runner := NewJobRunner(containerForTest(conf), conf)
+
+	_, statusCode, err := runner.Submit(context.Background(), domain.AnalyserRequest{Url: "not a url"})
+	assert.Error(t, err)
+	assert.Equal(t, int64(http.StatusBadRequest), statusCode)
+
+	job, _, err := runner.Submit(context.Background(), domain.AnalyserRequest{Url: server.URL})
+	assert.NoError(t, err)
+	job = waitForJob(t, runner, job.Id, domain.JobStatusCompleted, domain.JobStatusFailed)
+	assert.Equal(t, domain.JobStatusFailed, job.Status)
+	assert.Equal(t, int64(http.StatusNotFound), job.ErrorCode)
+	assert.Empty(t, job.ErrorType)
+}
+
+func TestJobDestinationNotAllowed(t *testing.T) {
+	server := siteForTest(t, map[string]string{"/": `<title>Internal</title>`})
+	conf := configForTest()
+	conf.OutboundConf.BlockPrivateNetworks = true
+	runner := NewJobRunner(containerForTest(conf), conf)
+
+	job, _, err := runner.Submit(context.Background(), domain.AnalyserRequest{Url: server.URL})
+	assert.NoError(t, err)
+	job = waitForJob(t, runner, job.Id, domain.JobStatusCompleted, domain.JobStatusFailed)
+	assert.Equal(t, domain.JobStatusFailed, job.Status)
+	assert.Equal(t, int64(http.StatusForbidden), job.ErrorCode)
+	assert.Equal(t, domain.ErrorTypeDestinationNotAllowed, job.ErrorType)
+}
+
+func TestJobConcurrencyAndCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		fmt.Fprint(w, `<html><head><title>Slow</title></head></html>`)
+	}))
+	defer server.Close()
+	defer close(release)
+	conf := configForTest()
+	conf.AppConfig.JobMaxQueued = 1
+	conf.OutboundConf.DialTimeout = 5000
+	runner := NewJobRunner(containerForTest(conf), conf)
+	ctx := context.Background()
+	req := domain.AnalyserRequest{Url: server.URL, Analysers: []string{"title"}}
+
+	running, _, err := runner.Submit(ctx, req)
+	assert.NoError(t, err)
+	waitForJob(t, runner, running.Id, domain.JobStatusRunning)
+
+	// a single job runs at a time, the next one waits
+	queued, _, err := runner.Submit(ctx, req)
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	queued, _ = runner.Get(ctx, queued.Id, nil)
+	assert.Equal(t, domain.JobStatusQueued, queued.Status)
+
+	// the queue holds a single job
+	_, statusCode, err := runner.Submit(ctx, req)
+	assert.ErrorIs(t, err, container.ErrJobQueueFull)
+	assert.Equal(t, int64(http.StatusServiceUnavailable), statusCode)
+
+	queued, found := runner.Cancel(ctx, queued.Id)
+	assert.True(t, found)
+	assert.Equal(t, domain.JobStatusCancelled, queued.Status)
+
+	running, _ = runner.Cancel(ctx, running.Id)
+	assert.Equal(t, domain.JobStatusCancelled, running.Status)
+	// the cancelled job frees its slot for the next one
+	next, _, err := runner.Submit(ctx, req)
+	assert.NoError(t, err)
+	waitForJob(t, runner, next.Id, domain.JobStatusRunning)
+	_, found = runner.Cancel(ctx, "unknown")
+	assert.False(t, found)
+	runner.Cancel(ctx, next.Id)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/usecase"
+	"net/http"
+	"time"
+)
+
+const (
+	sitemapPrefix = "service.sitemap "
+)
+
+type Sitemap interface {
+	Analyse(ctx context.Context, req domain.SitemapRequest) (res domain.SitemapResult, errorCode int64, err error)
+}
+
+type sitemap struct {
+	container container.Container
+	config    bootstrap.Config
+}
+
+func NewSitemap(ctr container.Container, config bootstrap.Config) Sitemap {
+	return &sitemap{
+		container: ctr,
+		config:    config,
+	}
+}
+
+// Analyse validates the sitemaps of the site and checks the urls they list,
+// within the deadline of the request capped by analysis_timeout
+func (s sitemap) Analyse(ctx context.Context, req domain.SitemapRequest) (res domain.SitemapResult, errorCode int64, err error) {
+	log.WithContext(ctx).Info(sitemapPrefix, "start to analyse the sitemap")
+	validatorObj := usecase.NewValidation()
+	if !validatorObj.IsValidUrl(ctx, req.Url) {
+		log.WithContext(ctx).Error(sitemapPrefix, "Invalid url")
+		return res, http.StatusBadRequest, errors.New("invalid url")
+	}
+
+	maxChecks := limit(req.MaxChecks, int(s.config.AppConfig.SitemapMaxChecks))
+	if req.BypassCache {
+		ctx = usecase.WithoutLinkCache(ctx)
+	}
+	if timeout := limit(req.Timeout, int(s.config.AppConfig.AnalysisTimeout)); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Millisecond*time.Duration(timeout))
+		defer cancel()
+	}
+	res, err = usecase.NewSitemap(s.container, s.config).Analyse(ctx, req.Url, maxChecks)
+	if err != nil {
+		log.WithContext(ctx).Error(sitemapPrefix, "Error in fetching the sitemap, err: ", err)
+		return res, http.StatusForbidden, err
+	}
+	return res, http.StatusOK, nil
+}
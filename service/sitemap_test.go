@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSitemapDestinationNotAllowed(t *testing.T) {
+	server := siteForTest(t, map[string]string{"/sitemap.xml": `<urlset></urlset>`})
+	conf := configForTest()
+	conf.OutboundConf.BlockPrivateNetworks = true
+	sitemapObj := NewSitemap(containerForTest(conf), conf)
+
+	_, statusCode, err := sitemapObj.Analyse(context.Background(), domain.SitemapRequest{Url: server.URL})
+	assert.ErrorIs(t, err, container.ErrDisallowedAddress)
+	assert.Equal(t, int64(http.StatusForbidden), statusCode)
+}
+
+func TestSitemapStopsOnTheAnalysisTimeout(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/slow</loc></url></urlset>`, server.URL)
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	conf := configForTest()
+	conf.AppConfig.AnalysisTimeout = 200
+	conf.AppConfig.SitemapMaxChecks = 10
+	sitemapObj := NewSitemap(containerForTest(conf), conf)
+
+	start := time.Now()
+	res, statusCode, err := sitemapObj.Analyse(context.Background(), domain.SitemapRequest{Url: server.URL, Timeout: 60000})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(http.StatusOK), statusCode)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	if assert.Len(t, res.Checks, 1) {
+		assert.True(t, res.Checks[0].Skipped)
+		assert.Empty(t, res.InaccessibleUrls)
+	}
+}
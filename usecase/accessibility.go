@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"strings"
+)
+
+const (
+	accessibilityPrefix = "usecase.accessibility "
+
+	wcagNonTextContent   = "1.1.1"
+	wcagInfoRelationship = "1.3.1"
+	wcagBypassBlocks     = "2.4.1"
+	wcagLinkPurpose      = "2.4.4"
+	wcagLanguageOfPage   = "3.1.1"
+	wcagLabels           = "3.3.2"
+	wcagParsing          = "4.1.1"
+	wcagNameRoleValue    = "4.1.2"
+)
+
+var (
+	// input types which need no label
+	unlabelledInputTypes = map[string]interface{}{
+		"hidden": nil, "submit": nil, "reset": nil, "button": nil, "image": nil,
+	}
+)
+
+type Accessibility interface {
+	Analyse(ctx context.Context, doc *goquery.Document) (accessibility domain.Accessibility)
+}
+
+type accessibility struct{}
+
+func (a accessibility) Analyse(ctx context.Context, doc *goquery.Document) (result domain.Accessibility) {
+	log.WithContext(ctx).Info(accessibilityPrefix, "start to audit the accessibility")
+	result.Findings = make([]domain.Finding, 0)
+	report := func(finding domain.Finding) {
+		result.Findings = append(result.Findings, finding)
+	}
+
+	result.Lang = strings.TrimSpace(doc.Find("html").AttrOr("lang", ""))
+	if result.Lang == "" {
+		report(a11yFinding("missing_lang", domain.SeverityError, wcagLanguageOfPage,
+			"the html element has no lang attribute", "html"))
+	}
+
+	a.checkImages(doc, report)
+	a.checkFormLabels(doc, report)
+	a.checkHeadingOrder(doc, report)
+	a.checkEmptyLinks(doc, report)
+	a.checkEmptyButtons(doc, report)
+	a.checkDuplicateIDs(doc, report)
+	a.checkLandmarks(doc, report)
+	a.checkTables(doc, report)
+
+	for _, finding := range result.Findings {
+		switch finding.Severity {
+		case domain.SeverityError:
+			result.ErrorCount++
+		case domain.SeverityWarning:
+			result.WarningCount++
+		}
+	}
+	return result
+}
+
+func NewAccessibility() Accessibility {
+	return &accessibility{}
+}
+
+func (a accessibility) checkImages(doc *goquery.Document, report func(domain.Finding)) {
+	doc.Find("img, input[type='image' i], area[href]").Each(func(i int, s *goquery.Selection) {
+		if _, ok := s.Attr("alt"); ok || isHiddenFromAssistiveTech(s) || hasAccessibleName(s) {
+			return
+		}
+		report(a11yFinding("image_missing_alt", domain.SeverityError, wcagNonTextContent,
+			fmt.Sprintf("%s has no alt text", goquery.NodeName(s)), cssPath(s)))
+	})
+}
+
+func (a accessibility) checkFormLabels(doc *goquery.Document, report func(domain.Finding)) {
+	doc.Find("input, select, textarea").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "input" {
+			inputType := strings.ToLower(s.AttrOr("type", "text"))
+			if _, ok := unlabelledInputTypes[inputType]; ok {
+				return
+			}
+		}
+		if hasAccessibleName(s) || s.Closest("label").Length() > 0 {
+			return
+		}
+		if id, ok := s.Attr("id"); ok && id != "" {
+			labelled := false
+			doc.Find("label[for]").EachWithBreak(func(j int, label *goquery.Selection) bool {
+				labelled = label.AttrOr("for", "") == id
+				return !labelled
+			})
+			if labelled {
+				return
+			}
+		}
+		report(a11yFinding("input_missing_label", domain.SeverityError, wcagLabels,
+			fmt.Sprintf("%s has no label", goquery.NodeName(s)), cssPath(s)))
+	})
+}
+
+func (a accessibility) checkHeadingOrder(doc *goquery.Document, report func(domain.Finding)) {
+	previous := 0
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
+		level := int(goquery.NodeName(s)[1] - '0')
+		if previous > 0 && level > previous+1 {
+			report(a11yFinding("heading_level_skipped", domain.SeverityWarning, wcagInfoRelationship,
+				fmt.Sprintf("heading jumps from h%d to h%d", previous, level), cssPath(s)))
+		}
+		previous = level
+	})
+}
+
+func (a accessibility) checkEmptyLinks(doc *goquery.Document, report func(domain.Finding)) {
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		if isHiddenFromAssistiveTech(s) || hasAccessibleName(s) || hasContent(s) {
+			return
+		}
+		report(a11yFinding("empty_link", domain.SeverityError, wcagLinkPurpose,
+			"link has no text", cssPath(s)))
+	})
+}
+
+func (a accessibility) checkEmptyButtons(doc *goquery.Document, report func(domain.Finding)) {
+	doc.Find("button, [role='button']").Each(func(i int, s *goquery.Selection) {
+		if isHiddenFromAssistiveTech(s) || hasAccessibleName(s) || hasContent(s) {
+			return
+		}
+		report(a11yFinding("empty_button", domain.SeverityError, wcagNameRoleValue,
+			"button has no text", cssPath(s)))
+	})
+	doc.Find("input[type='button' i], input[type='submit' i], input[type='reset' i]").Each(func(i int, s *goquery.Selection) {
+		inputType := strings.ToLower(s.AttrOr("type", ""))
+		// submit and reset buttons get a default caption from the browser
+		if inputType != "button" || strings.TrimSpace(s.AttrOr("value", "")) != "" || hasAccessibleName(s) {
+			return
+		}
+		report(a11yFinding("empty_button", domain.SeverityError, wcagNameRoleValue,
+			"button has no text", cssPath(s)))
+	})
+}
+
+func (a accessibility) checkDuplicateIDs(doc *goquery.Document, report func(domain.Finding)) {
+	seen := make(map[string]interface{})
+	reported := make(map[string]interface{})
+	doc.Find("[id]").Each(func(i int, s *goquery.Selection) {
+		id := s.AttrOr("id", "")
+		if id == "" {
+			return
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = nil
+			return
+		}
+		if _, ok := reported[id]; ok {
+			return
+		}
+		reported[id] = nil
+		report(a11yFinding("duplicate_id", domain.SeverityWarning, wcagParsing,
+			fmt.Sprintf("id %q is used more than once", id), cssPath(s)))
+	})
+}
+
+func (a accessibility) checkLandmarks(doc *goquery.Document, report func(domain.Finding)) {
+	if doc.Find("main, [role='main']").Length() == 0 {
+		report(a11yFinding("missing_main_landmark", domain.SeverityWarning, wcagBypassBlocks,
+			"the page has no main landmark", "body"))
+	}
+	if doc.Find("nav, [role='navigation']").Length() == 0 && doc.Find("a[href]").Length() > 0 {
+		report(a11yFinding("missing_navigation_landmark", domain.SeverityInfo, wcagBypassBlocks,
+			"the page has links but no navigation landmark", "body"))
+	}
+}
+
+func (a accessibility) checkTables(doc *goquery.Document, report func(domain.Finding)) {
+	doc.Find("table").Each(func(i int, s *goquery.Selection) {
+		role := strings.ToLower(s.AttrOr("role", ""))
+		if role == "presentation" || role == "none" {
+			return
+		}
+		if s.Find("th, [scope]").Length() > 0 {
+			return
+		}
+		report(a11yFinding("table_missing_headers", domain.SeverityError, wcagInfoRelationship,
+			"table has no header cells", cssPath(s)))
+	})
+}
+
+func hasAccessibleName(s *goquery.Selection) bool {
+	for _, attr := range []string{"aria-label", "aria-labelledby", "title"} {
+		if strings.TrimSpace(s.AttrOr(attr, "")) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasContent reports whether the element has text or an image with alt text
+func hasContent(s *goquery.Selection) bool {
+	if strings.TrimSpace(s.Text()) != "" {
+		return true
+	}
+	named := false
+	s.Find("img[alt], svg[aria-label], [aria-label]").EachWithBreak(func(i int, child *goquery.Selection) bool {
+		named = strings.TrimSpace(child.AttrOr("alt", child.AttrOr("aria-label", ""))) != ""
+		return !named
+	})
+	return named
+}
+
+func isHiddenFromAssistiveTech(s *goquery.Selection) bool {
+	role := strings.ToLower(s.AttrOr("role", ""))
+	return s.AttrOr("aria-hidden", "") == "true" || role == "presentation" || role == "none"
+}
+
+// cssPath builds a selector which points to the element,
+// it stops at the closest ancestor with an id
+func cssPath(s *goquery.Selection) string {
+	parts := make([]string, 0)
+	for current := s.First(); current.Length() > 0; current = current.Parent() {
+		name := goquery.NodeName(current)
+		if name == "" || name == "#document" {
+			break
+		}
+		if id := current.AttrOr("id", ""); id != "" {
+			parts = append([]string{name + "#" + cssEscape(id)}, parts...)
+			break
+		}
+		if siblings := current.Parent().Children().Filter(name); siblings.Length() > 1 {
+			name = fmt.Sprintf("%s:nth-of-type(%d)", name, siblings.IndexOfSelection(current)+1)
+		}
+		parts = append([]string{name}, parts...)
+	}
+	return strings.Join(parts, " > ")
+}
+
+// cssEscape escapes the id to be used as a css identifier, as CSS.escape does in the browsers
+func cssEscape(id string) string {
+	var escaped strings.Builder
+	for i, r := range id {
+		switch {
+		case r == 0:
+			escaped.WriteRune('\uFFFD')
+		case r < 0x20 || r == 0x7f,
+			r >= '0' && r <= '9' && (i == 0 || i == 1 && id[0] == '-'):
+			fmt.Fprintf(&escaped, "\\%x ", r)
+		case r == '-' && len(id) == 1:
+			escaped.WriteString("\\-")
+		case r >= 0x80, r == '-', r == '_', r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			escaped.WriteRune(r)
+		default:
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
+
+func a11yFinding(code, severity, criterion, message, selector string) domain.Finding {
+	finding := newFinding(code, severity, message)
+	finding.WcagCriterion = criterion
+	finding.Selector = selector
+	return finding
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"testing"
+)
+
+func TestAccessibilityAnalyse(t *testing.T) {
+	var (
+		htmlForAccessibility = `
+<!DOCTYPE html>
+<html>
+<body>
+    <nav><a href="/"><img src="logo.png"></a></nav>
+    <div id="content">
+        <h1>Title</h1>
+        <h3>Skipped</h3>
+        <form>
+            <input type="text" name="q">
+            <label for="mail">Mail</label><input type="email" id="mail">
+            <label>Name <input type="text" name="name"></label>
+            <input type="hidden" name="token">
+            <button></button>
+            <button aria-label="Close"></button>
+        </form>
+        <a href="/empty"></a>
+        <table><tr><td>1</td></tr></table>
+        <span id="dup"></span><span id="dup"></span>
+    </div>
+</body>
+</html>
+`
+	)
+	ctx := context.Background()
+
+	actual := NewAccessibility().Analyse(ctx, docFromHTML(t, htmlForAccessibility))
+	assert.ElementsMatch(t, []string{"missing_lang", "image_missing_alt", "empty_link", "heading_level_skipped",
+		"input_missing_label", "empty_button", "empty_link", "duplicate_id", "missing_main_landmark",
+		"table_missing_headers"}, findingCodes(actual.Findings))
+
+	selectors := make(map[string]string)
+	for _, finding := range actual.Findings {
+		selectors[finding.Code] = finding.Selector
+		assert.NotEmpty(t, finding.WcagCriterion, finding.Code)
+	}
+	assert.Equal(t, "html > body > nav > a > img", selectors["image_missing_alt"])
+	assert.Equal(t, "div#content > form > input:nth-of-type(1)", selectors["input_missing_label"])
+	assert.Equal(t, "div#content > h3", selectors["heading_level_skipped"])
+	assert.Equal(t, "div#content > form > button:nth-of-type(1)", selectors["empty_button"])
+	assert.Equal(t, "span#dup", selectors["duplicate_id"])
+	assert.Equal(t, 7, actual.ErrorCount)
+	assert.Equal(t, 3, actual.WarningCount)
+}
+
+func TestAccessibilityAnalyseClean(t *testing.T) {
+	var (
+		htmlForAccessibilityClean = `
+<!DOCTYPE html>
+<html lang="en">
+<body>
+    <nav><a href="/"><img src="logo.png" alt="Home"></a></nav>
+    <main>
+        <h1>Title</h1>
+        <h2>Section</h2>
+        <table><tr><th>Name</th></tr><tr><td>1</td></tr></table>
+        <input type="submit">
+    </main>
+</body>
+</html>
+`
+	)
+	ctx := context.Background()
+
+	actual := NewAccessibility().Analyse(ctx, docFromHTML(t, htmlForAccessibilityClean))
+	assert.Equal(t, "en", actual.Lang)
+	assert.Empty(t, actual.Findings)
+	assert.Equal(t, domain.Accessibility{Lang: "en", Findings: []domain.Finding{}}, actual)
+}
+
+func TestCSSPathEscapesIds(t *testing.T) {
+	doc := docFromHTML(t, `<html><body>
+    <div id="a.b"><img></div>
+    <div id="x:y"><img></div>
+    <div id="[z]"><img></div>
+    <div id="#h"><img></div>
+    <div id="1st"><img></div>
+    <div id="-2"><img></div>
+    <div id="two words"><img></div>
+</body></html>`)
+
+	expected := []string{`div#a\.b > img`, `div#x\:y > img`, `div#\[z\] > img`, `div#\#h > img`, `div#\31 st > img`,
+		`div#-\32  > img`, `div#two\ words > img`}
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		selector := cssPath(s)
+		assert.Equal(t, expected[i], selector)
+		// the selector finds the element it was built for
+		matched := doc.Find(selector)
+		if assert.Equal(t, 1, matched.Length(), selector) {
+			assert.True(t, matched.IsSelection(s), selector)
+		}
+	})
+}
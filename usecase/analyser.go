@@ -8,6 +8,7 @@ import (
 	"github.com/web-page-analysis/bootstrap"
 	"github.com/web-page-analysis/container"
 	"github.com/web-page-analysis/domain"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -30,6 +31,19 @@ type Analyser interface {
 	CheckAnyLogin(ctx context.Context, doc *goquery.Document) (loginExist bool)
 }
 
+type linkJob struct {
+	index    int
+	href     string
+	url      string
+	linkType string
+	text     string
+}
+
+type indexedLinkDetail struct {
+	index  int
+	detail domain.LinkDetail
+}
+
 type analyser struct {
 	ctr    container.Container
 	config bootstrap.Config
@@ -108,14 +122,27 @@ func (a analyser) CountLinks(ctx context.Context, doc *goquery.Document, baseURL
 	var (
 		link          domain.Link
 		linkLock      sync.Mutex
-		urlChannel    = make(chan string)
+		linkChannel   = make(chan linkJob)
 		wg            sync.WaitGroup
 		distinctLinks = make(map[string]interface{})
+		details       = make([]indexedLinkDetail, 0)
 	)
 
 	link.InaccessibleLink = make([]string, 0)
+	link.InternalLink = make([]string, 0)
+	link.RedirectedLinks = make([]domain.RedirectChain, 0)
+	link.RobotsDisallowedLinks = make([]string, 0)
+	link.FlakyLinks = make([]string, 0)
+	link.Details = make([]domain.LinkDetail, 0)
 
-	baseURL = normalizeURL(baseURL)
+	resolver, err := newLinkResolver(baseURL, doc, a.config.AppConfig.LinkClassification)
+	if err != nil {
+		log.WithContext(ctx).Error(analyserPrefix, "invalid base url, err: ", err)
+		return link
+	}
+	progress := Progress(ctx)
+	robotsConf := a.config.AppConfig.Robots
+	robotsObj := NewRobots(a.ctr, a.config)
 
 	// initiate the worker pool with the config value
 	// only to check the accessibility of the links
@@ -123,34 +150,57 @@ func (a analyser) CountLinks(ctx context.Context, doc *goquery.Document, baseURL
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for url := range urlChannel {
-				var inaccessible bool
+			for job := range linkChannel {
+				disallowed, robotsUnreachable := robotsCheck(ctx, robotsObj, robotsConf, job.url)
+				detail := domain.LinkDetail{Url: job.url, RobotsDisallowed: disallowed, Skipped: disallowed && robotsConf.Respect}
+				if !detail.Skipped {
+					detail = a.checkLink(ctx, job.url)
+					detail.RobotsDisallowed = disallowed
+				}
+				detail.RobotsUnreachable = robotsUnreachable
+				detail.Href = job.href
+				detail.AnchorText = job.text
+				detail.Type = job.linkType
+				detail.Internal = job.linkType == domain.LinkTypeInternal
 
-				fullURL := resolveURL(baseURL, url)
-				resp, err := a.ctr.OBAdapter.Get(ctx, fullURL)
-				if resp != nil && resp.Body != nil {
-					defer resp.Body.Close()
+				linkLock.Lock()
+				if detail.Redirect != nil {
+					link.RedirectedLinks = append(link.RedirectedLinks, *detail.Redirect)
 				}
 
-				if err != nil || resp != nil && (resp.StatusCode > 300 || resp.StatusCode < 200) {
-					log.WithContext(ctx).Error(analyserPrefix,
-						"inaccessible link, err: ", err, " url: ", url, "resp: ", resp)
-					inaccessible = true
+				if detail.RobotsDisallowed {
+					link.RobotsDisallowedLinks = append(link.RobotsDisallowedLinks, job.url)
 				}
 
-				linkLock.Lock()
+				if detail.Accessible && detail.Retries > 0 {
+					link.FlakyLinks = append(link.FlakyLinks, job.url)
+				}
 
-				if strings.HasPrefix(fullURL, baseURL) {
+				// a skipped internal link is not handed to the crawler either
+				if detail.Type == domain.LinkTypeInternal {
 					link.InternalLinks++
+					if !detail.Skipped {
+						link.InternalLink = append(link.InternalLink, job.url)
+					}
 				} else {
 					link.ExternalLinks++
 				}
 
-				if inaccessible {
+				if !detail.Accessible && !detail.Skipped {
 					link.InaccessibleLinkCount++
-					link.InaccessibleLink = append(link.InaccessibleLink, fullURL)
+					link.InaccessibleLink = append(link.InaccessibleLink, job.url)
 				}
+				details = append(details, indexedLinkDetail{index: job.index, detail: detail})
 				linkLock.Unlock()
+				progress.LinkChecked(domain.LinkCheck{
+					Url:        detail.Url,
+					StatusCode: detail.StatusCode,
+					LatencyMs:  detail.ResponseTimeMs,
+					Accessible: detail.Accessible,
+					Error:      detail.Error,
+					Attempts:   detail.Attempts,
+					CacheHit:   detail.CacheHit,
+				})
 			}
 		}()
 	}
@@ -159,21 +209,57 @@ func (a analyser) CountLinks(ctx context.Context, doc *goquery.Document, baseURL
 	// then get the href
 	// ignore the #
 	doc.Find("a").Each(func(i int, s *goquery.Selection) {
-		url, exists := s.Attr("href")
-		if !exists || strings.HasPrefix(url, "#") {
+		href, exists := s.Attr("href")
+		if !exists || strings.TrimSpace(href) == "" || strings.HasPrefix(href, "#") {
+			return
+		}
+		fullURL, linkType, err := resolver.resolve(href)
+		if err != nil {
+			log.WithContext(ctx).Error(analyserPrefix, "cannot resolve the link: ", href, " err: ", err)
 			return
 		}
 
 		// add in to map to get the distinct links
-		_, ok := distinctLinks[url]
-		if !ok {
-			distinctLinks[url] = nil
-			urlChannel <- url
+		_, ok := distinctLinks[fullURL]
+		if ok {
+			return
 		}
+		distinctLinks[fullURL] = nil
+		job := linkJob{
+			index:    len(distinctLinks),
+			href:     href,
+			url:      fullURL,
+			linkType: linkType,
+			text:     strings.Join(strings.Fields(s.Text()), " "),
+		}
+
+		// mailto, tel, javascript and the like cannot be checked over http
+		if linkType == domain.LinkTypeNonHTTP {
+			linkLock.Lock()
+			link.NonHTTPLinks++
+			details = append(details, indexedLinkDetail{index: job.index, detail: domain.LinkDetail{
+				Url:        job.url,
+				Href:       job.href,
+				AnchorText: job.text,
+				Type:       job.linkType,
+			}})
+			linkLock.Unlock()
+			return
+		}
+		progress.LinkFound(fullURL)
+		linkChannel <- job
 	})
 
-	close(urlChannel)
+	close(linkChannel)
 	wg.Wait()
+
+	// keep the records in the order the links appear in the page
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].index < details[j].index
+	})
+	for _, d := range details {
+		link.Details = append(link.Details, d.detail)
+	}
 	return link
 }
 
@@ -183,16 +269,3 @@ func NewAnalyser(ctr container.Container, cfg bootstrap.Config) Analyser {
 		config: cfg,
 	}
 }
-func normalizeURL(url string) string {
-	return strings.TrimSuffix(url, "/")
-}
-
-func resolveURL(base, href string) string {
-	if strings.HasPrefix(href, "http") {
-		return href
-	}
-	if strings.HasPrefix(href, "/") {
-		return base + href
-	}
-	return base + "/" + href
-}
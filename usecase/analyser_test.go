@@ -7,9 +7,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/web-page-analysis/bootstrap"
 	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -27,6 +29,14 @@ func (o mockOutBoundConnection) Get(ctx context.Context, url string) (*http.Resp
 
 }
 
+func (o mockOutBoundConnection) GetUnverified(ctx context.Context, url string) (*http.Response, error) {
+	return mockOutboundResp, mockOutBoundError
+}
+
+func (o mockOutBoundConnection) Check(ctx context.Context, url string) (*http.Response, error) {
+	return mockOutboundResp, mockOutBoundError
+}
+
 func docFromHTML(t *testing.T, html string) *goquery.Document {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
@@ -402,3 +412,57 @@ func TestCheckAnyLoginWithPassword(t *testing.T) {
 	actual := analyser.CheckAnyLogin(ctx, docFromHTML(t, htmlForLoginCheckWithPassword))
 	assert.Equal(t, true, actual)
 }
+
+type mockProgress struct {
+	lock    sync.Mutex
+	found   int
+	checked int
+}
+
+func (m *mockProgress) PhaseCompleted(phase string, value interface{}) {}
+
+func (m *mockProgress) LinkFound(url string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.found++
+}
+
+func (m *mockProgress) LinkChecked(check domain.LinkCheck) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.checked++
+}
+
+func TestCountLinksReportsProgress(t *testing.T) {
+	var (
+		htmlForProgress = `
+<!DOCTYPE html>
+<html>
+<body>
+    <a href="/about">About Us</a>
+    <a href="/contact">Contact</a>
+    <a href="/contact">Contact</a>
+    <a href="https://example.com">Example</a>
+</body>
+</html>
+`
+	)
+	progress := &mockProgress{}
+	ctx := WithProgressListener(context.Background(), progress)
+	ctr := container.Container{OBAdapter: mockOutBoundConnection{}}
+	mockOutBoundError = nil
+	mockOutboundResp = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	conf := bootstrap.Config{
+		AppConfig: bootstrap.AppConfig{
+			WorkerCount: 200,
+		},
+	}
+	analyser := NewAnalyser(ctr, conf)
+
+	analyser.CountLinks(ctx, docFromHTML(t, htmlForProgress), "http://abc.com/")
+	assert.Equal(t, 3, progress.found)
+	assert.Equal(t, 3, progress.checked)
+}
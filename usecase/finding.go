@@ -0,0 +1,7 @@
+package usecase
+
+import "github.com/web-page-analysis/domain"
+
+func newFinding(code, severity, message string) domain.Finding {
+	return domain.Finding{Code: code, Severity: severity, Message: message}
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+type linkCacheBypassKey struct{}
+
+type linkCheckPoolKey struct{}
+
+// WithoutLinkCache returns a ctx in which the links are checked again instead of taken
+// from the cache, the new results still replace the cached ones
+func WithoutLinkCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, linkCacheBypassKey{}, true)
+}
+
+func linkCacheBypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(linkCacheBypassKey{}).(bool)
+	return bypassed
+}
+
+// WithLinkCheckPool returns a ctx in which no more than size links are requested
+// at the same time, however many pages are analysed with it
+func WithLinkCheckPool(ctx context.Context, size int) context.Context {
+	if size <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, linkCheckPoolKey{}, make(chan struct{}, size))
+}
+
+// acquireLinkCheck waits for a place in the pool of the ctx, when it has one
+func acquireLinkCheck(ctx context.Context) (release func(), err error) {
+	pool, ok := ctx.Value(linkCheckPoolKey{}).(chan struct{})
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case pool <- struct{}{}:
+		return func() { <-pool }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// checkLink returns the cached result of the url when there is one,
+// otherwise it requests the url and caches how it answered
+func (a analyser) checkLink(ctx context.Context, fullURL string) domain.LinkDetail {
+	useCache := a.config.AppConfig.LinkCache.Enabled && a.ctr.LinkCache != nil
+	if useCache && !linkCacheBypassed(ctx) {
+		if detail, ok := a.ctr.LinkCache.Get(fullURL); ok {
+			detail.Url = fullURL
+			detail.CacheHit = true
+			return detail
+		}
+	}
+	release, err := acquireLinkCheck(ctx)
+	if err != nil {
+		return domain.LinkDetail{Url: fullURL, Skipped: true, Error: err.Error()}
+	}
+	detail := a.requestLink(ctx, fullURL)
+	release()
+	// a check stopped by the deadline or the caller says nothing about the link
+	if ctx.Err() != nil {
+		if detail.StatusCode == 0 {
+			return domain.LinkDetail{Url: fullURL, Skipped: true, Error: ctx.Err().Error()}
+		}
+		return detail
+	}
+	if useCache && cacheable(detail) {
+		a.ctr.LinkCache.Set(fullURL, detail)
+	}
+	return detail
+}
+
+// cacheable leaves out the failures which may be gone by the next check, a server error
+// or a 429 included, one of them would otherwise mark the link broken for every analysis within the ttl
+func cacheable(detail domain.LinkDetail) bool {
+	switch detail.ErrorCategory {
+	case domain.LinkErrorTimeout, domain.LinkErrorConnection, domain.LinkErrorConnectionRefused,
+		domain.LinkErrorHTTP5xx:
+		return false
+	}
+	return detail.StatusCode != http.StatusTooManyRequests
+}
+
+// requestLink requests the url and records how it answered
+func (a analyser) requestLink(ctx context.Context, fullURL string) (detail domain.LinkDetail) {
+	detail.Url = fullURL
+	start := time.Now()
+	ctx, attempts := container.WithAttempts(ctx)
+	resp, err := a.ctr.OBAdapter.Check(ctx, fullURL)
+	detail.ResponseTimeMs = time.Since(start).Milliseconds()
+	detail.Attempts = attempts.Sent
+	detail.Retries = attempts.Retries
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp != nil {
+		detail.StatusCode = resp.StatusCode
+		detail.ContentType = resp.Header.Get("Content-Type")
+		detail.Redirect = NewRedirect().Chain(ctx, resp)
+	}
+	if err != nil || resp != nil && (resp.StatusCode > 300 || resp.StatusCode < 200) {
+		log.WithContext(ctx).Error(analyserPrefix,
+			"inaccessible link, err: ", err, " url: ", fullURL, "resp: ", resp)
+		detail.ErrorCategory = linkErrorCategory(err, detail.StatusCode)
+		if err != nil {
+			detail.Error = err.Error()
+		} else {
+			detail.Error = fmt.Sprintf("status %d", detail.StatusCode)
+		}
+		return detail
+	}
+	detail.Accessible = true
+	return detail
+}
+
+// linkErrorCategory maps the outbound error or the status code of an
+// inaccessible link to one of the domain.LinkError categories
+func linkErrorCategory(err error, statusCode int) string {
+	if err != nil {
+		var (
+			dnsErr *net.DNSError
+			netErr net.Error
+		)
+		switch {
+		case errors.Is(err, container.ErrDisallowedAddress):
+			return domain.LinkErrorBlocked
+		case errors.As(err, &dnsErr):
+			return domain.LinkErrorDNS
+		case errors.Is(err, context.DeadlineExceeded),
+			errors.As(err, &netErr) && netErr.Timeout():
+			return domain.LinkErrorTimeout
+		case IsCertificateError(err):
+			return domain.LinkErrorTLS
+		case errors.Is(err, syscall.ECONNREFUSED):
+			return domain.LinkErrorConnectionRefused
+		default:
+			return domain.LinkErrorConnection
+		}
+	}
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return domain.LinkErrorHTTP5xx
+	case statusCode >= http.StatusBadRequest:
+		return domain.LinkErrorHTTP4xx
+	case statusCode >= http.StatusMultipleChoices:
+		return domain.LinkErrorRedirect
+	default:
+		return domain.LinkErrorHTTPStatus
+	}
+}
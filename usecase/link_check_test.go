@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"github.com/web-page-analysis/util/testserver"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type timeoutError struct{}
+
+func (t timeoutError) Error() string   { return "i/o timeout" }
+func (t timeoutError) Timeout() bool   { return true }
+func (t timeoutError) Temporary() bool { return true }
+
+func TestLinkErrorCategory(t *testing.T) {
+	wrap := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://abc.com", Err: err}
+	}
+	assert.Equal(t, domain.LinkErrorDNS, linkErrorCategory(wrap(&net.DNSError{Err: "no such host"}), 0))
+	assert.Equal(t, domain.LinkErrorTimeout, linkErrorCategory(wrap(timeoutError{}), 0))
+	assert.Equal(t, domain.LinkErrorConnectionRefused, linkErrorCategory(wrap(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), 0))
+	assert.Equal(t, domain.LinkErrorBlocked, linkErrorCategory(wrap(fmt.Errorf("%w: 127.0.0.1", container.ErrDisallowedAddress)), 0))
+	assert.Equal(t, domain.LinkErrorConnection, linkErrorCategory(errors.New("connection reset"), 0))
+	assert.Equal(t, domain.LinkErrorHTTP4xx, linkErrorCategory(nil, http.StatusNotFound))
+	assert.Equal(t, domain.LinkErrorHTTP5xx, linkErrorCategory(nil, http.StatusServiceUnavailable))
+	assert.Equal(t, domain.LinkErrorRedirect, linkErrorCategory(nil, http.StatusFound))
+}
+
+func TestCountLinksDetails(t *testing.T) {
+	var (
+		htmlForLinkDetails = `
+<!DOCTYPE html>
+<html>
+<body>
+    <a href="/about">About
+        Us</a>
+    <a href="https://example.com">Example</a>
+    <a href="/about">About again</a>
+</body>
+</html>
+`
+	)
+	ctx := context.Background()
+	ctr := container.Container{OBAdapter: mockOutBoundConnection{}}
+	mockOutBoundError = nil
+	mockOutboundResp = &http.Response{
+		StatusCode: 404,
+		Header:     http.Header{"Content-Type": []string{"text/html"}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	conf := bootstrap.Config{
+		AppConfig: bootstrap.AppConfig{
+			WorkerCount: 200,
+		},
+	}
+	analyser := NewAnalyser(ctr, conf)
+
+	actual := analyser.CountLinks(ctx, docFromHTML(t, htmlForLinkDetails), "http://abc.com/")
+	assert.Len(t, actual.Details, 2)
+	assert.Equal(t, "/about", actual.Details[0].Href)
+	assert.Equal(t, "http://abc.com/about", actual.Details[0].Url)
+	assert.Equal(t, "About Us", actual.Details[0].AnchorText)
+	assert.Equal(t, domain.LinkTypeInternal, actual.Details[0].Type)
+	assert.True(t, actual.Details[0].Internal)
+	assert.Equal(t, 404, actual.Details[0].StatusCode)
+	assert.Equal(t, domain.LinkErrorHTTP4xx, actual.Details[0].ErrorCategory)
+	assert.Equal(t, "text/html", actual.Details[0].ContentType)
+	assert.Equal(t, domain.LinkTypeExternal, actual.Details[1].Type)
+	assert.False(t, actual.Details[1].Internal)
+}
+
+func TestCountLinksFlaky(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/nohead", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	var (
+		htmlForFlaky = `<a href="/ok">ok</a><a href="/flaky">flaky</a><a href="/down">down</a><a href="/nohead">nohead</a>`
+	)
+	ctx := context.Background()
+	ctr := container.Container{OBAdapter: container.InitOutBoundConnection(bootstrap.Config{
+		OutboundConf: bootstrap.OutboundConfig{
+			DialTimeout: 1000,
+			Retry: bootstrap.RetryConfig{
+				MaxAttempts:       2,
+				BaseBackoff:       10,
+				RetryableStatuses: []int{http.StatusServiceUnavailable},
+			},
+			LinkCheck: bootstrap.LinkCheckConfig{HeadFirst: true, FallbackStatuses: []int{http.StatusMethodNotAllowed}},
+		},
+	})}
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{WorkerCount: 2}}
+
+	actual := NewAnalyser(ctr, conf).CountLinks(ctx, docFromHTML(t, htmlForFlaky), server.URL+"/")
+	assert.Equal(t, 1, actual.Details[0].Attempts)
+	assert.Equal(t, 2, actual.Details[1].Attempts)
+	assert.Equal(t, 1, actual.Details[1].Retries)
+	assert.True(t, actual.Details[1].Accessible)
+	assert.Equal(t, 2, actual.Details[2].Attempts)
+	assert.False(t, actual.Details[2].Accessible)
+	// the GET sent after a refused HEAD is not a retry
+	assert.Equal(t, 2, actual.Details[3].Attempts)
+	assert.Equal(t, 0, actual.Details[3].Retries)
+	assert.Equal(t, []string{server.URL + "/flaky"}, actual.FlakyLinks)
+	assert.Equal(t, []string{server.URL + "/down"}, actual.InaccessibleLink)
+}
+
+func TestCountLinksUsesLinkCache(t *testing.T) {
+	var checked int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&checked, 1)
+	}))
+	defer server.Close()
+	var (
+		htmlForCache = `<a href="/a">a</a><a href="/b">b</a>`
+	)
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		WorkerCount: 2,
+		LinkCache:   bootstrap.LinkCacheConfig{Enabled: true},
+	}}
+	ctr := container.Container{OBAdapter: outboundForTest(10), LinkCache: container.InitLinkCache(conf)}
+	analyser := NewAnalyser(ctr, conf)
+
+	actual := analyser.CountLinks(ctx, docFromHTML(t, htmlForCache), server.URL+"/")
+	assert.False(t, actual.Details[0].CacheHit)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&checked))
+
+	// the second page reuses the results of the first one
+	actual = analyser.CountLinks(ctx, docFromHTML(t, htmlForCache), server.URL+"/other")
+	assert.True(t, actual.Details[0].CacheHit)
+	assert.True(t, actual.Details[1].CacheHit)
+	assert.Equal(t, server.URL+"/b", actual.Details[1].Url)
+	assert.Equal(t, 200, actual.Details[1].StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&checked))
+
+	actual = analyser.CountLinks(WithoutLinkCache(ctx), docFromHTML(t, htmlForCache), server.URL+"/")
+	assert.False(t, actual.Details[0].CacheHit)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&checked))
+}
+
+func TestCountLinksDoesNotCacheTransientFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		WorkerCount: 2,
+		LinkCache:   bootstrap.LinkCacheConfig{Enabled: true},
+	}}
+	ctr := container.Container{OBAdapter: outboundForTest(10), LinkCache: container.InitLinkCache(conf)}
+
+	actual := NewAnalyser(ctr, conf).CountLinks(ctx,
+		docFromHTML(t, `<a href="/missing">missing</a><a href="`+down.URL+`/">down</a>`+
+			`<a href="/unavailable">unavailable</a><a href="/limited">limited</a>`), server.URL+"/")
+	assert.Equal(t, domain.LinkErrorHTTP4xx, actual.Details[0].ErrorCategory)
+	assert.Equal(t, domain.LinkErrorConnectionRefused, actual.Details[1].ErrorCategory)
+	assert.Equal(t, domain.LinkErrorHTTP5xx, actual.Details[2].ErrorCategory)
+	assert.Equal(t, http.StatusTooManyRequests, actual.Details[3].StatusCode)
+	// a 404 is there to stay, a refused connection, a server error or a 429 may not be
+	_, cached := ctr.LinkCache.Get(server.URL + "/missing")
+	assert.True(t, cached)
+	for _, link := range []string{down.URL + "/", server.URL + "/unavailable", server.URL + "/limited"} {
+		_, cached = ctr.LinkCache.Get(link)
+		assert.False(t, cached, link)
+	}
+}
+
+func TestCountLinksStopsAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	var (
+		htmlForDeadline = `<a href="/a">a</a><a href="/b">b</a>`
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		WorkerCount: 2,
+		LinkCache:   bootstrap.LinkCacheConfig{Enabled: true},
+	}}
+	ctr := container.Container{OBAdapter: outboundForTest(10), LinkCache: container.InitLinkCache(conf)}
+
+	actual := NewAnalyser(ctr, conf).CountLinks(ctx, docFromHTML(t, htmlForDeadline), server.URL+"/")
+	assert.Equal(t, 2, actual.InternalLinks)
+	assert.Equal(t, 0, actual.InaccessibleLinkCount)
+	assert.True(t, actual.Details[0].Skipped)
+	assert.Empty(t, actual.Details[0].ErrorCategory)
+	// the unfinished checks are not cached
+	_, cached := ctr.LinkCache.Get(server.URL + "/a")
+	assert.False(t, cached)
+}
+
+func TestLinkCheckPoolIsShared(t *testing.T) {
+	server := testserver.NewConcurrencyServer(10 * time.Millisecond)
+	defer server.Close()
+	var (
+		htmlForPool = `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/d">d</a>`
+	)
+	ctx := WithLinkCheckPool(context.Background(), 2)
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{WorkerCount: 4}}
+	analyser := NewAnalyser(container.Container{OBAdapter: outboundForTest(10)}, conf)
+
+	// two pages analysed side by side share the two places of the pool
+	done := make(chan domain.Link, 2)
+	for _, page := range []string{"/one/", "/two/"} {
+		go func(page string) {
+			done <- analyser.CountLinks(ctx, docFromHTML(t, htmlForPool), server.URL+page)
+		}(page)
+	}
+	for i := 0; i < 2; i++ {
+		link := <-done
+		assert.Equal(t, 0, link.InaccessibleLinkCount)
+	}
+	assert.Equal(t, int32(2), server.MaxInFlight())
+}
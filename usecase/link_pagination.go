@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"net/url"
+	"strings"
+)
+
+const (
+	paginationPrefix = "usecase.link_pagination "
+	DefaultPageSize  = 50
+	MaxPageSize      = 500
+)
+
+type LinkPagination interface {
+	Paginate(ctx context.Context, link domain.Link, filter domain.LinkFilter) (paged domain.Link)
+}
+
+type linkPagination struct{}
+
+// Paginate filters the link records and keeps the requested page of them.
+// The inaccessible, redirected, disallowed and flaky link lists are narrowed down
+// to the records of the page, the inaccessible link count with them, the total
+// of the page analysed moves to the pagination. The other link counts stay the totals
+func (l linkPagination) Paginate(ctx context.Context, link domain.Link, filter domain.LinkFilter) (paged domain.Link) {
+	log.WithContext(ctx).Info(paginationPrefix, "start to paginate the links")
+	page, pageSize := filter.Page, filter.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	matched := make([]domain.LinkDetail, 0)
+	for _, detail := range link.Details {
+		if matchLinkFilter(detail, filter) {
+			matched = append(matched, detail)
+		}
+	}
+
+	pagination := &domain.Pagination{
+		Page:              page,
+		PageSize:          pageSize,
+		TotalItems:        len(matched),
+		TotalPages:        (len(matched) + pageSize - 1) / pageSize,
+		TotalInaccessible: link.InaccessibleLinkCount,
+	}
+	start := (page - 1) * pageSize
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	paged = link
+	paged.Details = matched[start:end]
+	paged.Pagination = pagination
+	paged.InaccessibleLink = make([]string, 0)
+	paged.RedirectedLinks = nil
+	paged.RobotsDisallowedLinks = make([]string, 0)
+	paged.FlakyLinks = make([]string, 0)
+	for _, detail := range paged.Details {
+		if detail.RobotsDisallowed {
+			paged.RobotsDisallowedLinks = append(paged.RobotsDisallowedLinks, detail.Url)
+		}
+		if detail.Accessible && detail.Retries > 0 {
+			paged.FlakyLinks = append(paged.FlakyLinks, detail.Url)
+		}
+		if detail.ErrorCategory != "" && !detail.Skipped {
+			paged.InaccessibleLink = append(paged.InaccessibleLink, detail.Url)
+		}
+		if detail.Redirect != nil {
+			paged.RedirectedLinks = append(paged.RedirectedLinks, *detail.Redirect)
+		}
+	}
+	paged.InaccessibleLinkCount = len(paged.InaccessibleLink)
+	return paged
+}
+
+func NewLinkPagination() LinkPagination {
+	return &linkPagination{}
+}
+
+// IsValidLinkType reports whether the link type can be used in a filter
+func IsValidLinkType(linkType string) bool {
+	switch linkType {
+	case "", domain.LinkTypeInternal, domain.LinkTypeExternal, domain.LinkTypeNonHTTP:
+		return true
+	}
+	return false
+}
+
+// IsValidStatusClass reports whether the status class can be used in a filter
+func IsValidStatusClass(statusClass string) bool {
+	switch statusClass {
+	case "", "2xx", "3xx", "4xx", "5xx", domain.StatusClassError, domain.StatusClassInaccessible:
+		return true
+	}
+	return false
+}
+
+func matchLinkFilter(detail domain.LinkDetail, filter domain.LinkFilter) bool {
+	if filter.Type != "" && filter.Type != detail.Type {
+		return false
+	}
+
+	if filter.Host != "" {
+		parsed, err := url.Parse(detail.Url)
+		if err != nil || !strings.EqualFold(parsed.Hostname(), filter.Host) {
+			return false
+		}
+	}
+
+	switch filter.StatusClass {
+	case "":
+		return true
+	case domain.StatusClassError:
+		return detail.StatusCode == 0 && detail.ErrorCategory != ""
+	case domain.StatusClassInaccessible:
+		return detail.ErrorCategory != ""
+	default:
+		return detail.StatusCode != 0 && filter.StatusClass[0] == byte('0'+detail.StatusCode/100)
+	}
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"testing"
+)
+
+func linksForPagination() domain.Link {
+	return domain.Link{
+		InternalLinks:         3,
+		ExternalLinks:         2,
+		InaccessibleLinkCount: 3,
+		InaccessibleLink:      []string{"http://abc.com/b", "http://abc.com/c", "https://cdn.org/y"},
+		Details: []domain.LinkDetail{
+			{Url: "http://abc.com/a", Type: domain.LinkTypeInternal, StatusCode: 200, Accessible: true},
+			{Url: "http://abc.com/b", Type: domain.LinkTypeInternal, StatusCode: 404, ErrorCategory: domain.LinkErrorHTTP4xx},
+			{Url: "http://abc.com/c", Type: domain.LinkTypeInternal, StatusCode: 503, ErrorCategory: domain.LinkErrorHTTP5xx},
+			{Url: "https://cdn.org/x", Type: domain.LinkTypeExternal, StatusCode: 200, Accessible: true},
+			{Url: "https://cdn.org/y", Type: domain.LinkTypeExternal, ErrorCategory: domain.LinkErrorDNS},
+		},
+	}
+}
+
+func TestPaginateLinks(t *testing.T) {
+	ctx := context.Background()
+	paged := NewLinkPagination().Paginate(ctx, linksForPagination(), domain.LinkFilter{Page: 2, PageSize: 2})
+
+	assert.Equal(t, &domain.Pagination{Page: 2, PageSize: 2, TotalItems: 5, TotalPages: 3, TotalInaccessible: 3}, paged.Pagination)
+	assert.Len(t, paged.Details, 2)
+	assert.Equal(t, "http://abc.com/c", paged.Details[0].Url)
+	// the list and its count are of the records of the page, the total moves to the pagination
+	assert.Equal(t, []string{"http://abc.com/c"}, paged.InaccessibleLink)
+	assert.Equal(t, 1, paged.InaccessibleLinkCount)
+	assert.Equal(t, 3, paged.Pagination.TotalInaccessible)
+	assert.Equal(t, 3, paged.InternalLinks)
+}
+
+func TestPaginateLinksWithFilter(t *testing.T) {
+	ctx := context.Background()
+	pagination := NewLinkPagination()
+
+	paged := pagination.Paginate(ctx, linksForPagination(), domain.LinkFilter{StatusClass: "4xx"})
+	assert.Len(t, paged.Details, 1)
+	assert.Equal(t, "http://abc.com/b", paged.Details[0].Url)
+
+	paged = pagination.Paginate(ctx, linksForPagination(), domain.LinkFilter{StatusClass: domain.StatusClassError})
+	assert.Len(t, paged.Details, 1)
+	assert.Equal(t, "https://cdn.org/y", paged.Details[0].Url)
+
+	paged = pagination.Paginate(ctx, linksForPagination(), domain.LinkFilter{Type: domain.LinkTypeExternal, StatusClass: "2xx"})
+	assert.Len(t, paged.Details, 1)
+	assert.Equal(t, "https://cdn.org/x", paged.Details[0].Url)
+
+	paged = pagination.Paginate(ctx, linksForPagination(), domain.LinkFilter{Host: "ABC.com", StatusClass: domain.StatusClassInaccessible})
+	assert.Len(t, paged.Details, 2)
+	assert.Equal(t, 2, paged.Pagination.TotalItems)
+}
+
+func TestPaginateLinksOutOfRange(t *testing.T) {
+	ctx := context.Background()
+	paged := NewLinkPagination().Paginate(ctx, linksForPagination(), domain.LinkFilter{Page: 10, PageSize: 2})
+
+	assert.Len(t, paged.Details, 0)
+	assert.Equal(t, 3, paged.Pagination.TotalPages)
+}
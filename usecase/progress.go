@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"github.com/web-page-analysis/domain"
+)
+
+type progressKey struct{}
+
+// ProgressListener receives the progress of an analysis while it runs
+type ProgressListener interface {
+	PhaseCompleted(phase string, value interface{})
+	LinkFound(url string)
+	LinkChecked(check domain.LinkCheck)
+}
+
+type noopProgress struct{}
+
+func (n noopProgress) PhaseCompleted(phase string, value interface{}) {}
+func (n noopProgress) LinkFound(url string)                           {}
+func (n noopProgress) LinkChecked(check domain.LinkCheck)             {}
+
+// WithProgressListener attaches the listener to the context,
+// every analysis step run with the returned context reports to it
+func WithProgressListener(ctx context.Context, listener ProgressListener) context.Context {
+	return context.WithValue(ctx, progressKey{}, listener)
+}
+
+// Progress returns the listener attached to the context
+// or a listener which ignores the events
+func Progress(ctx context.Context) ProgressListener {
+	listener, ok := ctx.Value(progressKey{}).(ProgressListener)
+	if !ok || listener == nil {
+		return noopProgress{}
+	}
+	return listener
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+)
+
+const (
+	redirectPrefix = "usecase.redirect "
+)
+
+type Redirect interface {
+	Chain(ctx context.Context, resp *http.Response) (chain *domain.RedirectChain)
+}
+
+type redirect struct{}
+
+// Chain rebuilds the redirect chain which led to the response,
+// it returns nil when the response was not redirected
+func (r redirect) Chain(ctx context.Context, resp *http.Response) (chain *domain.RedirectChain) {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+	hops := make([]domain.RedirectHop, 0)
+	// every request made for a redirect keeps the response which caused it,
+	// so the chain is walked backwards from the last request
+	for req := resp.Request; req.Response != nil && req.Response.Request != nil; req = req.Response.Request {
+		previous := req.Response
+		hops = append([]domain.RedirectHop{
+			newRedirectHop(previous.Request.URL.String(), previous.StatusCode, req.URL.String()),
+		}, hops...)
+	}
+
+	chain = &domain.RedirectChain{
+		FinalUrl:        resp.Request.URL.String(),
+		FinalStatusCode: resp.StatusCode,
+	}
+
+	// the client stops on loops and on too long chains
+	// and hands back the redirect response itself
+	if isRedirect(resp.StatusCode) {
+		location, err := resp.Location()
+		if err == nil {
+			hops = append(hops, newRedirectHop(chain.FinalUrl, resp.StatusCode, location.String()))
+			chain.Loop = visited(hops, location.String())
+			chain.TooManyHops = !chain.Loop
+		}
+	}
+	if len(hops) == 0 {
+		return nil
+	}
+
+	chain.Url = hops[0].Url
+	chain.Hops = hops
+	chain.Permanent = true
+	for _, hop := range hops {
+		chain.Permanent = chain.Permanent && hop.Permanent
+	}
+	if chain.Loop || chain.TooManyHops {
+		log.WithContext(ctx).Error(redirectPrefix, "redirect chain stopped, url: ", chain.Url,
+			" loop: ", chain.Loop, " hops: ", len(hops))
+	}
+	return chain
+}
+
+// RedirectFindings reports a chain the client stopped following
+func RedirectFindings(chain *domain.RedirectChain) []domain.Finding {
+	findings := make([]domain.Finding, 0)
+	switch {
+	case chain == nil:
+	case chain.Loop:
+		findings = append(findings, newFinding("redirect_loop", domain.SeverityError,
+			fmt.Sprintf("the redirects of %s loop back to %s", chain.Url, chain.Hops[len(chain.Hops)-1].Location)))
+	case chain.TooManyHops:
+		findings = append(findings, newFinding("redirect_too_many_hops", domain.SeverityError,
+			fmt.Sprintf("the redirects of %s were stopped after %d hops", chain.Url, len(chain.Hops))))
+	}
+	return findings
+}
+
+func NewRedirect() Redirect {
+	return &redirect{}
+}
+
+func newRedirectHop(url string, statusCode int, location string) domain.RedirectHop {
+	return domain.RedirectHop{
+		Url:        url,
+		StatusCode: statusCode,
+		Location:   location,
+		Permanent:  statusCode == http.StatusMovedPermanently || statusCode == http.StatusPermanentRedirect,
+	}
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+func visited(hops []domain.RedirectHop, url string) bool {
+	for _, hop := range hops {
+		if hop.Url == url {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func redirectServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/old", http.RedirectHandler("/moved", http.StatusMovedPermanently))
+	mux.Handle("/moved", http.RedirectHandler("/new", http.StatusFound))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("/loop-a", http.RedirectHandler("/loop-b", http.StatusFound))
+	mux.Handle("/loop-b", http.RedirectHandler("/loop-a", http.StatusFound))
+	return httptest.NewServer(mux)
+}
+
+func outboundForTest(maxRedirects int64) container.OutBoundConnection {
+	return container.InitOutBoundConnection(bootstrap.Config{
+		OutboundConf: bootstrap.OutboundConfig{
+			DialTimeout:  1000,
+			MaxRedirects: maxRedirects,
+		},
+	})
+}
+
+func TestRedirectChain(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	resp, err := outboundForTest(10).Get(ctx, server.URL+"/old")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	chain := NewRedirect().Chain(ctx, resp)
+	assert.NotNil(t, chain)
+	assert.Equal(t, server.URL+"/old", chain.Url)
+	assert.Equal(t, server.URL+"/new", chain.FinalUrl)
+	assert.Equal(t, http.StatusOK, chain.FinalStatusCode)
+	assert.Len(t, chain.Hops, 2)
+	assert.Equal(t, http.StatusMovedPermanently, chain.Hops[0].StatusCode)
+	assert.True(t, chain.Hops[0].Permanent)
+	assert.Equal(t, http.StatusFound, chain.Hops[1].StatusCode)
+	assert.False(t, chain.Hops[1].Permanent)
+	assert.False(t, chain.Permanent)
+	assert.False(t, chain.Loop)
+}
+
+func TestRedirectChainLoop(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	resp, err := outboundForTest(10).Get(ctx, server.URL+"/loop-a")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	chain := NewRedirect().Chain(ctx, resp)
+	assert.True(t, chain.Loop)
+	assert.False(t, chain.TooManyHops)
+	assert.Equal(t, http.StatusFound, chain.FinalStatusCode)
+	assert.Equal(t, []string{"redirect_loop"}, findingCodes(RedirectFindings(chain)))
+}
+
+func TestRedirectChainTooManyHops(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	resp, err := outboundForTest(1).Get(ctx, server.URL+"/old")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	chain := NewRedirect().Chain(ctx, resp)
+	assert.True(t, chain.TooManyHops)
+	assert.False(t, chain.Loop)
+	assert.Len(t, chain.Hops, 2)
+	assert.Equal(t, []string{"redirect_too_many_hops"}, findingCodes(RedirectFindings(chain)))
+}
+
+func TestRedirectChainWithoutRedirect(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	resp, err := outboundForTest(10).Get(ctx, server.URL+"/new")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Nil(t, NewRedirect().Chain(ctx, resp))
+}
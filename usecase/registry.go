@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// names of the built in page analysers, they double as the progress phases
+const (
+	AnalyserTitle           = "title"
+	AnalyserHtmlVersion     = "html_version"
+	AnalyserLogin           = "login"
+	AnalyserLinks           = "links"
+	AnalyserHeadings        = "headings"
+	AnalyserSEO             = "seo"
+	AnalyserStructuredData  = "structured_data"
+	AnalyserAccessibility   = "accessibility"
+	AnalyserRules           = "rules"
+	AnalyserSecurityHeaders = "security_headers"
+	AnalyserTLS             = "tls"
+	AnalyserRobots          = "robots"
+)
+
+// PageInput is what every page analyser gets to work on
+type PageInput struct {
+	Doc      *goquery.Document
+	RawHTML  string
+	Response PageResponse
+	Request  domain.AnalyserRequest
+}
+
+// PageResponse describes how the page was served
+type PageResponse struct {
+	// Url is the url the page was served from, after the redirects
+	Url        string
+	StatusCode int
+	Header     http.Header
+	TLS        *tls.ConnectionState
+	Redirect   *domain.RedirectChain
+}
+
+// Section is the output of a page analyser, Value holds the typed section
+// which is reported as the phase value and apply writes it into the result
+type Section struct {
+	Name     string
+	Value    interface{}
+	Findings []domain.Finding
+	apply    func(result *domain.AnalysisResult)
+}
+
+// Apply writes the section into the analysis result
+func (s Section) Apply(result *domain.AnalysisResult) {
+	if s.apply != nil {
+		s.apply(result)
+	}
+}
+
+// NewSection builds a section which is written into the result by apply
+func NewSection(value interface{}, findings []domain.Finding, apply func(result *domain.AnalysisResult)) Section {
+	return Section{
+		Value:    value,
+		Findings: findings,
+		apply:    apply,
+	}
+}
+
+type PageAnalyser interface {
+	Name() string
+	Analyse(ctx context.Context, input PageInput) Section
+}
+
+type Registry interface {
+	// Register adds the analyser, an analyser with the same name is replaced
+	Register(analyser PageAnalyser)
+	Names() []string
+	// Select returns the analysers with the given names in the registration order,
+	// all of them when no name is given
+	Select(names []string) ([]PageAnalyser, error)
+}
+
+type pageAnalyser struct {
+	name    string
+	analyse func(ctx context.Context, input PageInput) Section
+}
+
+type registry struct {
+	analysers []PageAnalyser
+}
+
+func (p pageAnalyser) Name() string {
+	return p.name
+}
+
+func (p pageAnalyser) Analyse(ctx context.Context, input PageInput) Section {
+	section := p.analyse(ctx, input)
+	section.Name = p.name
+	return section
+}
+
+// NewPageAnalyser wraps a function as a named page analyser
+func NewPageAnalyser(name string, analyse func(ctx context.Context, input PageInput) Section) PageAnalyser {
+	return &pageAnalyser{
+		name:    name,
+		analyse: analyse,
+	}
+}
+
+func (r *registry) Register(analyser PageAnalyser) {
+	for i, existing := range r.analysers {
+		if existing.Name() == analyser.Name() {
+			r.analysers[i] = analyser
+			return
+		}
+	}
+	r.analysers = append(r.analysers, analyser)
+}
+
+func (r *registry) Names() []string {
+	names := make([]string, 0, len(r.analysers))
+	for _, analyser := range r.analysers {
+		names = append(names, analyser.Name())
+	}
+	return names
+}
+
+func (r *registry) Select(names []string) ([]PageAnalyser, error) {
+	if len(names) == 0 {
+		return append([]PageAnalyser{}, r.analysers...), nil
+	}
+	wanted := make(map[string]interface{})
+	unknown := make([]string, 0)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !r.has(name) {
+			unknown = append(unknown, name)
+			continue
+		}
+		wanted[name] = nil
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown analysers: %s, available: %s",
+			strings.Join(unknown, ", "), strings.Join(r.Names(), ", "))
+	}
+
+	selected := make([]PageAnalyser, 0, len(wanted))
+	for _, analyser := range r.analysers {
+		if _, ok := wanted[analyser.Name()]; ok {
+			selected = append(selected, analyser)
+		}
+	}
+	return selected, nil
+}
+
+// SelectForHTML selects the analysers able to run on html supplied by the caller,
+// the ones reading the http response never can and the ones working from the page
+// url only can with a base url. Left out quietly when all analysers are asked for,
+// they are an error when asked for by name
+func SelectForHTML(reg Registry, names []string, hasBaseURL bool) ([]PageAnalyser, error) {
+	analysers, err := reg.Select(names)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]PageAnalyser, 0, len(analysers))
+	unavailable := make([]string, 0)
+	for _, analyser := range analysers {
+		switch analyser.Name() {
+		case AnalyserSecurityHeaders, AnalyserTLS:
+			unavailable = append(unavailable, analyser.Name())
+		case AnalyserLinks, AnalyserRobots:
+			if !hasBaseURL {
+				unavailable = append(unavailable, analyser.Name())
+				continue
+			}
+			selected = append(selected, analyser)
+		default:
+			selected = append(selected, analyser)
+		}
+	}
+	if len(names) > 0 && len(unavailable) > 0 {
+		return nil, fmt.Errorf("analysers not available on supplied html: %s, "+
+			"links and robots need a base url, security_headers and tls a fetched page", strings.Join(unavailable, ", "))
+	}
+	return selected, nil
+}
+
+func (r *registry) has(name string) bool {
+	for _, analyser := range r.analysers {
+		if analyser.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() Registry {
+	return &registry{analysers: make([]PageAnalyser, 0)}
+}
+
+// NewDefaultRegistry returns the registry with the built in analysers,
+// a new check is added by registering it here
+func NewDefaultRegistry(ctr container.Container, config bootstrap.Config) Registry {
+	analyserObj := NewAnalyser(ctr, config)
+	reg := NewRegistry()
+
+	reg.Register(NewPageAnalyser(AnalyserTitle, func(ctx context.Context, input PageInput) Section {
+		title := analyserObj.GetTitle(ctx, input.Doc)
+		return NewSection(title, nil, func(result *domain.AnalysisResult) {
+			result.Title = title
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserHtmlVersion, func(ctx context.Context, input PageInput) Section {
+		htmlVersion := analyserObj.CheckHtmlVersion(ctx, input.RawHTML)
+		return NewSection(htmlVersion, nil, func(result *domain.AnalysisResult) {
+			result.HTMLVersion = htmlVersion
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserLogin, func(ctx context.Context, input PageInput) Section {
+		login := analyserObj.CheckAnyLogin(ctx, input.Doc)
+		return NewSection(login, nil, func(result *domain.AnalysisResult) {
+			result.HasLoginForm = login
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserLinks, func(ctx context.Context, input PageInput) Section {
+		link := analyserObj.CountLinks(ctx, input.Doc, input.Response.Url)
+		return NewSection(link, nil, func(result *domain.AnalysisResult) {
+			result.Link = link
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserHeadings, func(ctx context.Context, input PageInput) Section {
+		headings := analyserObj.CountHeading(ctx, input.Doc)
+		return NewSection(headings, nil, func(result *domain.AnalysisResult) {
+			result.Headings = headings
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserSEO, func(ctx context.Context, input PageInput) Section {
+		seo := NewSEO().Analyse(ctx, input.Doc)
+		return NewSection(seo, seo.Findings, func(result *domain.AnalysisResult) {
+			result.SEO = seo
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserStructuredData, func(ctx context.Context, input PageInput) Section {
+		data := NewStructuredData().Analyse(ctx, input.Doc)
+		return NewSection(data, data.Findings, func(result *domain.AnalysisResult) {
+			result.StructuredData = data
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserAccessibility, func(ctx context.Context, input PageInput) Section {
+		a11y := NewAccessibility().Analyse(ctx, input.Doc)
+		return NewSection(a11y, a11y.Findings, func(result *domain.AnalysisResult) {
+			result.Accessibility = a11y
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserRules, func(ctx context.Context, input PageInput) Section {
+		rules := NewRules(config.RulesConf).Analyse(ctx, input.Doc, input.Response.Url)
+		return NewSection(rules, rules.Findings, func(result *domain.AnalysisResult) {
+			result.Rules = rules
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserSecurityHeaders, func(ctx context.Context, input PageInput) Section {
+		headers := NewSecurityHeaders().Analyse(ctx, input.Response.Header, input.Response.Url)
+		return NewSection(headers, headers.Findings, func(result *domain.AnalysisResult) {
+			result.SecurityHeaders = headers
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserTLS, func(ctx context.Context, input PageInput) Section {
+		tlsInfo := NewTLS().Analyse(ctx, input.Response.TLS, input.Response.Url)
+		return NewSection(tlsInfo, tlsInfo.Findings, func(result *domain.AnalysisResult) {
+			result.TLS = tlsInfo
+		})
+	}))
+	reg.Register(NewPageAnalyser(AnalyserRobots, func(ctx context.Context, input PageInput) Section {
+		// robots.txt is not fetched at all when it is turned off
+		if !config.AppConfig.Robots.Enabled {
+			return NewSection(domain.Robots{}, nil, nil)
+		}
+		robots := NewRobots(ctr, config).Summary(ctx, input.Response.Url)
+		return NewSection(robots, robots.Findings, func(result *domain.AnalysisResult) {
+			result.Robots = robots
+		})
+	}))
+	return reg
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"testing"
+)
+
+func TestDefaultRegistrySelect(t *testing.T) {
+	ctr := container.Container{OBAdapter: mockOutBoundConnection{}}
+	conf := bootstrap.Config{}
+	registry := NewDefaultRegistry(ctr, conf)
+
+	all, err := registry.Select(nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 12)
+
+	// the registration order is kept whatever order the names are given in
+	selected, err := registry.Select([]string{AnalyserSEO, AnalyserTitle, AnalyserSEO})
+	assert.NoError(t, err)
+	assert.Len(t, selected, 2)
+	assert.Equal(t, AnalyserTitle, selected[0].Name())
+	assert.Equal(t, AnalyserSEO, selected[1].Name())
+}
+
+func TestDefaultRegistrySelectUnknown(t *testing.T) {
+	ctr := container.Container{OBAdapter: mockOutBoundConnection{}}
+	conf := bootstrap.Config{}
+	registry := NewDefaultRegistry(ctr, conf)
+
+	selected, err := registry.Select([]string{AnalyserTitle, "spelling"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spelling")
+	assert.Nil(t, selected)
+}
+
+func TestDefaultRegistryAnalyse(t *testing.T) {
+	var (
+		htmlForRegistry = `
+<!DOCTYPE html>
+<html lang="en">
+<head><title>Test Page</title></head>
+<body><main><h1>Hello World</h1><h2>One</h2><h2>Two</h2></main></body>
+</html>
+`
+	)
+	ctx := context.Background()
+	ctr := container.Container{OBAdapter: mockOutBoundConnection{}}
+	conf := bootstrap.Config{}
+	input := PageInput{
+		Doc:     docFromHTML(t, htmlForRegistry),
+		RawHTML: htmlForRegistry,
+	}
+
+	selected, err := NewDefaultRegistry(ctr, conf).Select([]string{AnalyserTitle, AnalyserHeadings, AnalyserSEO})
+	assert.NoError(t, err)
+	result := domain.AnalysisResult{}
+	for _, analyser := range selected {
+		section := analyser.Analyse(ctx, input)
+		assert.Equal(t, analyser.Name(), section.Name)
+		section.Apply(&result)
+	}
+	assert.Equal(t, "Test Page", result.Title)
+	assert.Equal(t, 2, result.Headings["h2"])
+	assert.Contains(t, findingCodes(result.SEO.Findings), "title_too_short")
+	// the analysers which were not selected leave their sections empty
+	assert.Empty(t, result.HTMLVersion)
+	assert.Nil(t, result.Accessibility.Findings)
+}
+
+func TestRegistryRegister(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRegistry()
+	registry.Register(NewPageAnalyser("word_count", func(ctx context.Context, input PageInput) Section {
+		return NewSection(len(input.RawHTML), nil, nil)
+	}))
+	registry.Register(NewPageAnalyser("word_count", func(ctx context.Context, input PageInput) Section {
+		findings := []domain.Finding{newFinding("thin_content", domain.SeverityWarning, "the page has little text")}
+		return NewSection(0, findings, nil)
+	}))
+	assert.Equal(t, []string{"word_count"}, registry.Names())
+
+	selected, err := registry.Select([]string{"word_count"})
+	assert.NoError(t, err)
+	section := selected[0].Analyse(ctx, PageInput{})
+	assert.Equal(t, []string{"thin_content"}, findingCodes(section.Findings))
+	// a section without an apply function leaves the result as it is
+	result := domain.AnalysisResult{Title: "kept"}
+	section.Apply(&result)
+	assert.Equal(t, "kept", result.Title)
+}
+
+func TestSelectForHTML(t *testing.T) {
+	ctr := container.Container{OBAdapter: mockOutBoundConnection{}}
+	conf := bootstrap.Config{}
+	registry := NewDefaultRegistry(ctr, conf)
+
+	// the analysers which cannot run are left out when all of them are asked for
+	selected, err := SelectForHTML(registry, nil, false)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 8)
+	selected, err = SelectForHTML(registry, nil, true)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 10)
+
+	// and refused when asked for by name
+	_, err = SelectForHTML(registry, []string{AnalyserTitle, AnalyserTLS}, true)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), AnalyserTLS)
+	_, err = SelectForHTML(registry, []string{AnalyserLinks}, false)
+	assert.Error(t, err)
+	selected, err = SelectForHTML(registry, []string{AnalyserLinks}, true)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 1)
+}
@@ -0,0 +1,338 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	robotsPrefix = "usecase.robots "
+
+	// the part of a robots.txt crawlers have to read, the rest may be ignored
+	maxRobotsSize = 500 * 1024
+	wildcardAgent = "*"
+)
+
+type Robots interface {
+	// Fetch returns the robots.txt of the host of the url, it is cached per host
+	Fetch(ctx context.Context, rawURL string) domain.RobotsTxt
+	// Allowed tells whether the configured user agent may fetch the url, a robots.txt
+	// which could not be fetched at all allows it and sets fetchFailed
+	Allowed(ctx context.Context, rawURL string) (allowed bool, fetchFailed bool)
+	// CrawlDelay is the delay asked for by the host of the url, capped by the config
+	CrawlDelay(ctx context.Context, rawURL string) time.Duration
+	// Summary reports the robots.txt of the page host and whether the page may be fetched
+	Summary(ctx context.Context, pageURL string) domain.Robots
+}
+
+type robots struct {
+	ctr    container.Container
+	config bootstrap.RobotsConfig
+}
+
+// robotsGroup holds the rules of the groups applied to the user agent
+type robotsGroup struct {
+	agent      string
+	rules      []domain.RobotsRule
+	crawlDelay float64
+}
+
+func (r robots) Fetch(ctx context.Context, rawURL string) domain.RobotsTxt {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return domain.RobotsTxt{Groups: make([]domain.RobotsGroup, 0), Sitemaps: make([]string, 0)}
+	}
+	origin := strings.ToLower(target.Scheme + "://" + target.Host)
+	fetch := func() (domain.RobotsTxt, bool) {
+		robotsTxt := r.fetch(ctx, origin+"/robots.txt")
+		// a fetch stopped by the caller says nothing about the host
+		return robotsTxt, robotsTxt.FetchError == "" || ctx.Err() == nil
+	}
+	if r.ctr.RobotsCache == nil {
+		robotsTxt, _ := fetch()
+		return robotsTxt
+	}
+	for {
+		// the fetch of another caller which gave up is not taken, it is fetched again
+		robotsTxt, kept := r.ctr.RobotsCache.Get(origin, fetch)
+		if kept || ctx.Err() != nil {
+			return robotsTxt
+		}
+	}
+}
+
+func (r robots) Allowed(ctx context.Context, rawURL string) (allowed bool, fetchFailed bool) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return true, false
+	}
+	robotsTxt := r.Fetch(ctx, rawURL)
+	switch {
+	case robotsTxt.FetchError != "":
+		// skipping the link would hide that its host cannot be reached
+		return true, true
+	case robotsTxt.Unreachable:
+		return false, false
+	}
+	return matchGroup(robotsTxt, r.userAgent()).allows(robotsPath(target)), false
+}
+
+func (r robots) CrawlDelay(ctx context.Context, rawURL string) time.Duration {
+	delay := time.Duration(matchGroup(r.Fetch(ctx, rawURL), r.userAgent()).crawlDelay * float64(time.Second))
+	if maxDelay := time.Millisecond * time.Duration(r.config.MaxCrawlDelay); delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+func (r robots) Summary(ctx context.Context, pageURL string) (summary domain.Robots) {
+	log.WithContext(ctx).Info(robotsPrefix, "start to summarise the robots.txt")
+	robotsTxt := r.Fetch(ctx, pageURL)
+	group := matchGroup(robotsTxt, r.userAgent())
+	summary = domain.Robots{
+		Url:          robotsTxt.Url,
+		Found:        robotsTxt.StatusCode >= http.StatusOK && robotsTxt.StatusCode < http.StatusMultipleChoices,
+		StatusCode:   robotsTxt.StatusCode,
+		Unreachable:  robotsTxt.Unreachable,
+		UserAgent:    r.userAgent(),
+		MatchedGroup: group.agent,
+		Allow:        make([]string, 0),
+		Disallow:     make([]string, 0),
+		CrawlDelay:   group.crawlDelay,
+		Sitemaps:     robotsTxt.Sitemaps,
+		Findings:     make([]domain.Finding, 0),
+	}
+	if page, err := url.Parse(pageURL); err == nil {
+		summary.PageAllowed = !robotsTxt.Unreachable && group.allows(robotsPath(page))
+	}
+	for _, rule := range group.rules {
+		if rule.Allow {
+			summary.Allow = append(summary.Allow, rule.Path)
+		} else {
+			summary.Disallow = append(summary.Disallow, rule.Path)
+		}
+	}
+
+	switch {
+	case summary.Unreachable:
+		summary.Findings = append(summary.Findings, newFinding("robots_unreachable", domain.SeverityWarning,
+			"robots.txt cannot be fetched, crawlers treat the whole site as disallowed"))
+	case !summary.Found:
+		summary.Findings = append(summary.Findings, newFinding("robots_missing", domain.SeverityInfo,
+			"the site has no robots.txt"))
+	}
+	if !summary.Unreachable && !summary.PageAllowed {
+		summary.Findings = append(summary.Findings, newFinding("page_disallowed_by_robots", domain.SeverityWarning,
+			fmt.Sprintf("robots.txt disallows the page for %s", summary.UserAgent)))
+	}
+	return summary
+}
+
+func NewRobots(ctr container.Container, config bootstrap.Config) Robots {
+	return &robots{
+		ctr:    ctr,
+		config: config.AppConfig.Robots,
+	}
+}
+
+// fetch follows RFC 9309: a missing file allows everything,
+// a server error or a failed request disallows everything.
+// Allowed lets the links of a host through when its request failed
+func (r robots) fetch(ctx context.Context, robotsURL string) (robotsTxt domain.RobotsTxt) {
+	robotsTxt = domain.RobotsTxt{
+		Url:      robotsURL,
+		Groups:   make([]domain.RobotsGroup, 0),
+		Sitemaps: make([]string, 0),
+	}
+	resp, err := r.ctr.OBAdapter.Get(ctx, robotsURL)
+	if err != nil {
+		log.WithContext(ctx).Error(robotsPrefix, "Error in fetching robots.txt: ", robotsURL, " err: ", err)
+		robotsTxt.Unreachable = true
+		robotsTxt.FetchError = err.Error()
+		return robotsTxt
+	}
+	defer resp.Body.Close()
+	robotsTxt.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		robotsTxt.Unreachable = true
+		return robotsTxt
+	case resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices:
+		return robotsTxt
+	}
+	parsed := ParseRobots(io.LimitReader(resp.Body, maxRobotsSize))
+	robotsTxt.Groups = parsed.Groups
+	robotsTxt.Sitemaps = parsed.Sitemaps
+	return robotsTxt
+}
+
+// robotsCheck tells whether robots.txt disallows the link, and whether
+// it could not be fetched, when robots.txt is enabled. Once the caller gave up
+// nothing is fetched, the link is skipped by its check anyway
+func robotsCheck(ctx context.Context, robotsObj Robots, conf bootstrap.RobotsConfig, rawURL string) (disallowed bool, unreachable bool) {
+	if !conf.Enabled || ctx.Err() != nil {
+		return false, false
+	}
+	allowed, unreachable := robotsObj.Allowed(ctx, rawURL)
+	return !allowed, unreachable
+}
+
+func (r robots) userAgent() string {
+	if r.config.UserAgent == "" {
+		return wildcardAgent
+	}
+	return r.config.UserAgent
+}
+
+// ParseRobots reads the groups and the sitemaps of a robots.txt,
+// lines it does not know are ignored as the crawlers do
+func ParseRobots(reader io.Reader) (robotsTxt domain.RobotsTxt) {
+	robotsTxt.Groups = make([]domain.RobotsGroup, 0)
+	robotsTxt.Sitemaps = make([]string, 0)
+	var current *domain.RobotsGroup
+	// a user-agent line after the rules starts a new group
+	inRules := false
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				robotsTxt.Groups = append(robotsTxt.Groups, domain.RobotsGroup{
+					UserAgents: make([]string, 0),
+					Rules:      make([]domain.RobotsRule, 0),
+				})
+				current = &robotsTxt.Groups[len(robotsTxt.Groups)-1]
+				inRules = false
+			}
+			current.UserAgents = append(current.UserAgents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// an empty disallow allows everything, it adds no rule
+			if value != "" {
+				current.Rules = append(current.Rules, domain.RobotsRule{Allow: key == "allow", Path: value})
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if delay, err := strconv.ParseFloat(value, 64); err == nil && delay >= 0 {
+				current.CrawlDelay = delay
+			}
+		case "sitemap":
+			if value != "" {
+				robotsTxt.Sitemaps = append(robotsTxt.Sitemaps, value)
+			}
+		}
+	}
+	return robotsTxt
+}
+
+// matchGroup merges the groups naming the product token of the user agent,
+// the * groups apply when none does
+func matchGroup(robotsTxt domain.RobotsTxt, userAgent string) robotsGroup {
+	token := strings.ToLower(strings.TrimSpace(strings.SplitN(userAgent, "/", 2)[0]))
+	matched := robotsGroup{rules: make([]domain.RobotsRule, 0)}
+	fallback := robotsGroup{rules: make([]domain.RobotsRule, 0)}
+	for _, group := range robotsTxt.Groups {
+		for _, agent := range group.UserAgents {
+			target := &fallback
+			switch agent {
+			case token:
+				target = &matched
+			case wildcardAgent:
+			default:
+				continue
+			}
+			target.agent = agent
+			target.rules = append(target.rules, group.Rules...)
+			if group.CrawlDelay > target.crawlDelay {
+				target.crawlDelay = group.CrawlDelay
+			}
+			break
+		}
+	}
+	if matched.agent != "" {
+		return matched
+	}
+	return fallback
+}
+
+// allows applies the most specific rule matching the path, allow wins a tie
+func (g robotsGroup) allows(path string) bool {
+	allowed := true
+	longest := -1
+	for _, rule := range g.rules {
+		if !robotsPatternMatch(rule.Path, path) {
+			continue
+		}
+		if len(rule.Path) > longest || len(rule.Path) == longest && rule.Allow {
+			longest = len(rule.Path)
+			allowed = rule.Allow
+		}
+	}
+	return allowed
+}
+
+// robotsPatternMatch matches the path against a rule path,
+// * matches any characters and a trailing $ anchors the end
+func robotsPatternMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	if len(parts) == 1 {
+		return !anchored || path == parts[0]
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return true
+}
+
+func robotsPath(target *url.URL) string {
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	return path
+}
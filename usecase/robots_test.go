@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	robotsForTest = `
+# comments are ignored
+User-agent: *
+Disallow: /private/
+Allow: /private/public.html
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: web-page-analysis
+User-agent: other-bot
+Disallow: /admin
+Allow: /admin/help
+Crawl-delay: 0.5
+
+Sitemap: https://abc.com/sitemap.xml
+`
+)
+
+func TestParseRobots(t *testing.T) {
+	actual := ParseRobots(strings.NewReader(robotsForTest))
+	assert.Len(t, actual.Groups, 2)
+	assert.Equal(t, []string{"*"}, actual.Groups[0].UserAgents)
+	assert.Len(t, actual.Groups[0].Rules, 3)
+	assert.Equal(t, float64(2), actual.Groups[0].CrawlDelay)
+	assert.Equal(t, []string{"web-page-analysis", "other-bot"}, actual.Groups[1].UserAgents)
+	assert.Equal(t, []string{"https://abc.com/sitemap.xml"}, actual.Sitemaps)
+}
+
+func TestRobotsGroupAllows(t *testing.T) {
+	robotsTxt := ParseRobots(strings.NewReader(robotsForTest))
+
+	wildcard := matchGroup(robotsTxt, "some-bot/1.0")
+	assert.Equal(t, "*", wildcard.agent)
+	assert.False(t, wildcard.allows("/private/page.html"))
+	assert.True(t, wildcard.allows("/private/public.html"))
+	assert.False(t, wildcard.allows("/docs/file.pdf"))
+	assert.True(t, wildcard.allows("/docs/file.pdf?download=1"))
+	assert.True(t, wildcard.allows("/admin"))
+
+	// the named group replaces the * group, the product token is matched without its version
+	named := matchGroup(robotsTxt, "Web-Page-Analysis/2.1")
+	assert.Equal(t, "web-page-analysis", named.agent)
+	assert.Equal(t, 0.5, named.crawlDelay)
+	assert.False(t, named.allows("/admin/users"))
+	assert.True(t, named.allows("/admin/help"))
+	assert.True(t, named.allows("/private/page.html"))
+}
+
+func TestRobotsPatternMatch(t *testing.T) {
+	assert.True(t, robotsPatternMatch("/", "/anything"))
+	assert.True(t, robotsPatternMatch("/a*c", "/abbbc/d"))
+	assert.True(t, robotsPatternMatch("/a*c$", "/abc"))
+	assert.False(t, robotsPatternMatch("/a*c$", "/abcd"))
+	assert.True(t, robotsPatternMatch("/page$", "/page"))
+	assert.False(t, robotsPatternMatch("/page$", "/page/"))
+	assert.True(t, robotsPatternMatch("/*/edit", "/posts/1/edit"))
+	assert.False(t, robotsPatternMatch("/b", "/a/b"))
+}
+
+func TestRobotsFetchAndCache(t *testing.T) {
+	var fetched int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetched, 1)
+		fmt.Fprint(w, robotsForTest)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{Robots: bootstrap.RobotsConfig{
+		Enabled: true, UserAgent: "web-page-analysis", MaxCrawlDelay: 10000,
+	}}}
+	ctr := container.Container{
+		OBAdapter:   outboundForTest(10),
+		RobotsCache: container.InitRobotsCache(conf),
+	}
+	robotsObj := NewRobots(ctr, conf)
+
+	allowed, _ := robotsObj.Allowed(ctx, server.URL+"/admin/users")
+	assert.False(t, allowed)
+	allowed, _ = robotsObj.Allowed(ctx, server.URL+"/admin/help")
+	assert.True(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, robotsObj.CrawlDelay(ctx, server.URL+"/"))
+
+	summary := robotsObj.Summary(ctx, server.URL+"/admin")
+	assert.True(t, summary.Found)
+	assert.Equal(t, server.URL+"/robots.txt", summary.Url)
+	assert.Equal(t, []string{"/admin"}, summary.Disallow)
+	assert.Equal(t, []string{"/admin/help"}, summary.Allow)
+	assert.False(t, summary.PageAllowed)
+	assert.Equal(t, []string{"page_disallowed_by_robots"}, findingCodes(summary.Findings))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetched))
+}
+
+func TestRobotsMissingAndUnreachable(t *testing.T) {
+	mux := http.NewServeMux()
+	missing := httptest.NewServer(mux)
+	defer missing.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{Robots: bootstrap.RobotsConfig{Enabled: true}}}
+	robotsObj := NewRobots(container.Container{OBAdapter: outboundForTest(10)}, conf)
+
+	// a missing robots.txt allows everything
+	summary := robotsObj.Summary(ctx, missing.URL+"/page")
+	assert.False(t, summary.Found)
+	assert.True(t, summary.PageAllowed)
+	assert.Equal(t, []string{"robots_missing"}, findingCodes(summary.Findings))
+
+	// a server error disallows everything
+	allowed, fetchFailed := robotsObj.Allowed(ctx, broken.URL+"/page")
+	assert.False(t, allowed)
+	assert.False(t, fetchFailed)
+	summary = robotsObj.Summary(ctx, broken.URL+"/page")
+	assert.True(t, summary.Unreachable)
+	assert.Equal(t, []string{"robots_unreachable"}, findingCodes(summary.Findings))
+
+	// a host which cannot be reached at all is not skipped, its links are checked and found broken
+	down := httptest.NewServer(mux)
+	down.Close()
+	allowed, fetchFailed = robotsObj.Allowed(ctx, down.URL+"/page")
+	assert.True(t, allowed)
+	assert.True(t, fetchFailed)
+	summary = robotsObj.Summary(ctx, down.URL+"/page")
+	assert.True(t, summary.Unreachable)
+}
+
+func TestCountLinksChecksHostsWithoutRobots(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		WorkerCount: 2,
+		Robots:      bootstrap.RobotsConfig{Enabled: true, Respect: true},
+	}}
+	ctr := container.Container{
+		OBAdapter:   outboundForTest(10),
+		RobotsCache: container.InitRobotsCache(conf),
+	}
+
+	actual := NewAnalyser(ctr, conf).CountLinks(ctx, docFromHTML(t, `<a href="`+down.URL+`/page">dead</a>`), "http://abc.com/")
+	assert.Equal(t, 1, actual.InaccessibleLinkCount)
+	assert.Empty(t, actual.RobotsDisallowedLinks)
+	assert.False(t, actual.Details[0].Skipped)
+	assert.True(t, actual.Details[0].RobotsUnreachable)
+	assert.Equal(t, domain.LinkErrorConnectionRefused, actual.Details[0].ErrorCategory)
+}
+
+func TestCountLinksRespectsRobots(t *testing.T) {
+	var checked int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&checked, 1)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	var (
+		htmlForRobots = `<a href="/public">public</a><a href="/private/page">private</a>`
+	)
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		WorkerCount: 2,
+		Robots:      bootstrap.RobotsConfig{Enabled: true, Respect: true},
+	}}
+	ctr := container.Container{
+		OBAdapter:   outboundForTest(10),
+		RobotsCache: container.InitRobotsCache(conf),
+	}
+
+	actual := NewAnalyser(ctr, conf).CountLinks(ctx, docFromHTML(t, htmlForRobots), server.URL+"/")
+	assert.Equal(t, 2, actual.InternalLinks)
+	assert.Equal(t, []string{server.URL + "/private/page"}, actual.RobotsDisallowedLinks)
+	assert.Equal(t, []string{server.URL + "/public"}, actual.InternalLink)
+	assert.Equal(t, 0, actual.InaccessibleLinkCount)
+	assert.True(t, actual.Details[1].Skipped)
+	assert.True(t, actual.Details[1].RobotsDisallowed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&checked))
+
+	// without respect the disallowed link is still checked, it is only reported
+	conf.AppConfig.Robots.Respect = false
+	actual = NewAnalyser(ctr, conf).CountLinks(ctx, docFromHTML(t, htmlForRobots), server.URL+"/")
+	assert.Equal(t, []string{server.URL + "/private/page"}, actual.RobotsDisallowedLinks)
+	assert.Len(t, actual.InternalLink, 2)
+	assert.False(t, actual.Details[1].Skipped)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&checked))
+}
+
+func TestRobotsFetchStopsWithTheCaller(t *testing.T) {
+	var fetched int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetched, 1)
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{
+		WorkerCount: 2,
+		Robots:      bootstrap.RobotsConfig{Enabled: true, Respect: true},
+	}}
+	ctr := container.Container{
+		OBAdapter:   outboundForTest(10),
+		RobotsCache: container.InitRobotsCache(conf),
+	}
+	robotsObj := NewRobots(ctr, conf)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the links of a cancelled analysis fetch no robots.txt
+	links := `<a href="` + server.URL + `/private/a">a</a><a href="` + strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + `/b">b</a>`
+	actual := NewAnalyser(ctr, conf).CountLinks(cancelled, docFromHTML(t, links), "http://abc.com/")
+	assert.True(t, actual.Details[0].Skipped)
+	assert.False(t, actual.Details[0].RobotsDisallowed)
+	disallowed, _ := robotsCheck(cancelled, robotsObj, conf.AppConfig.Robots, server.URL+"/private/a")
+	assert.False(t, disallowed)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&fetched))
+
+	// a fetch stopped by the caller is not cached, the next caller fetches the file
+	robotsTxt := robotsObj.Fetch(cancelled, server.URL+"/private/a")
+	assert.NotEmpty(t, robotsTxt.FetchError)
+	allowed, fetchFailed := robotsObj.Allowed(context.Background(), server.URL+"/private/a")
+	assert.False(t, allowed)
+	assert.False(t, fetchFailed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetched))
+}
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"regexp"
+	"strings"
+)
+
+const (
+	rulesPrefix = "usecase.rules "
+
+	// failing elements reported per rule, the rest are only counted
+	maxRuleMessages = 10
+)
+
+type Rules interface {
+	Analyse(ctx context.Context, doc *goquery.Document, pageURL string) (rules domain.Rules)
+}
+
+type rules struct {
+	conf bootstrap.RulesConfig
+}
+
+// Analyse evaluates the configured rules against the document,
+// the rules are validated and compiled when the config is loaded
+func (r rules) Analyse(ctx context.Context, doc *goquery.Document, pageURL string) (result domain.Rules) {
+	log.WithContext(ctx).Info(rulesPrefix, "start to evaluate the custom rules")
+	result.Results = make([]domain.RuleResult, 0, len(r.conf.Rules))
+	result.Findings = make([]domain.Finding, 0)
+
+	for _, rule := range r.conf.Rules {
+		ruleResult := domain.RuleResult{
+			Name:        rule.Name,
+			Description: rule.Description,
+			Selector:    rule.Selector,
+		}
+		messages := r.evaluate(doc, pageURL, rule, &ruleResult)
+		switch {
+		case ruleResult.Status == domain.RuleStatusSkipped:
+		case len(messages) > 0:
+			ruleResult.Status = domain.RuleStatusFail
+			ruleResult.Messages = messages
+			result.Failed++
+			result.Findings = append(result.Findings, newFinding("rule_failed", ruleSeverity(rule),
+				fmt.Sprintf("rule %s failed: %s", rule.Name, messages[0])))
+		default:
+			ruleResult.Status = domain.RuleStatusPass
+			result.Passed++
+		}
+		result.Results = append(result.Results, ruleResult)
+	}
+	return result
+}
+
+func NewRules(conf bootstrap.RulesConfig) Rules {
+	return &rules{conf: conf}
+}
+
+// evaluate returns a message per failed assertion of the compiled rule
+func (r rules) evaluate(doc *goquery.Document, pageURL string, rule bootstrap.RuleConfig,
+	ruleResult *domain.RuleResult) []string {
+	if rule.CompiledUrlPattern != nil && !rule.CompiledUrlPattern.MatchString(pageURL) {
+		ruleResult.Status = domain.RuleStatusSkipped
+		return nil
+	}
+
+	matched := doc.FindMatcher(rule.CompiledSelector)
+	ruleResult.Matched = matched.Length()
+	messages := make([]string, 0)
+	if rule.Count != nil {
+		messages = append(messages, checkCount(*rule.Count, matched.Length())...)
+	}
+	if rule.Attribute != nil {
+		messages = append(messages, checkAttribute(*rule.Attribute, matched)...)
+	}
+	if rule.Text != nil {
+		messages = append(messages, checkText(*rule.Text, matched)...)
+	}
+	return messages
+}
+
+func checkCount(assertion bootstrap.CountAssertion, count int) []string {
+	messages := make([]string, 0)
+	if assertion.Equals != nil && count != *assertion.Equals {
+		messages = append(messages, fmt.Sprintf("matched %d elements, expected %d", count, *assertion.Equals))
+	}
+	if assertion.Min != nil && count < *assertion.Min {
+		messages = append(messages, fmt.Sprintf("matched %d elements, expected at least %d", count, *assertion.Min))
+	}
+	if assertion.Max != nil && count > *assertion.Max {
+		messages = append(messages, fmt.Sprintf("matched %d elements, expected at most %d", count, *assertion.Max))
+	}
+	return messages
+}
+
+func checkAttribute(assertion bootstrap.AttributeAssertion, matched *goquery.Selection) []string {
+	check := stringCheck(assertion.Equals, assertion.Contains, assertion.NotContains, assertion.MatchesPattern)
+	return eachFailure(matched, func(s *goquery.Selection) string {
+		value, exists := s.Attr(assertion.Name)
+		switch {
+		case assertion.Exists != nil && !*assertion.Exists:
+			if exists {
+				return fmt.Sprintf("has the attribute %s", assertion.Name)
+			}
+			return ""
+		case !exists:
+			return fmt.Sprintf("has no attribute %s", assertion.Name)
+		}
+		if problem := check(value); problem != "" {
+			return fmt.Sprintf("attribute %s %s", assertion.Name, problem)
+		}
+		return ""
+	})
+}
+
+func checkText(assertion bootstrap.TextAssertion, matched *goquery.Selection) []string {
+	check := stringCheck(assertion.Equals, assertion.Contains, assertion.NotContains, assertion.MatchesPattern)
+	return eachFailure(matched, func(s *goquery.Selection) string {
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if assertion.NotEmpty && text == "" {
+			return "has no text"
+		}
+		if problem := check(text); problem != "" {
+			return "text " + problem
+		}
+		return ""
+	})
+}
+
+// stringCheck builds the check shared by the attribute and the text assertions,
+// it returns why the value does not pass or an empty string
+func stringCheck(equals, contains, notContains string, pattern *regexp.Regexp) func(value string) string {
+	return func(value string) string {
+		switch {
+		case equals != "" && value != equals:
+			return fmt.Sprintf("is %q, expected %q", value, equals)
+		case contains != "" && !strings.Contains(value, contains):
+			return fmt.Sprintf("%q does not contain %q", value, contains)
+		case notContains != "" && strings.Contains(value, notContains):
+			return fmt.Sprintf("%q contains %q", value, notContains)
+		case pattern != nil && !pattern.MatchString(value):
+			return fmt.Sprintf("%q does not match %q", value, pattern.String())
+		}
+		return ""
+	}
+}
+
+// eachFailure runs the check on every element and reports the failing ones by their path
+func eachFailure(matched *goquery.Selection, check func(s *goquery.Selection) string) []string {
+	messages := make([]string, 0)
+	failed := 0
+	matched.Each(func(i int, s *goquery.Selection) {
+		problem := check(s)
+		if problem == "" {
+			return
+		}
+		failed++
+		if failed <= maxRuleMessages {
+			messages = append(messages, fmt.Sprintf("%s %s", cssPath(s), problem))
+		}
+	})
+	if failed > maxRuleMessages {
+		messages = append(messages, fmt.Sprintf("%d more elements failed", failed-maxRuleMessages))
+	}
+	return messages
+}
+
+// ruleSeverity returns the severity of the rule findings, warning when none is set
+func ruleSeverity(rule bootstrap.RuleConfig) string {
+	if rule.Severity == "" {
+		return domain.SeverityWarning
+	}
+	return rule.Severity
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+func rulesFromYAML(t *testing.T, rules string) bootstrap.RulesConfig {
+	conf := bootstrap.RulesConfig{}
+	err := yaml.Unmarshal([]byte(rules), &conf)
+	assert.NoError(t, err)
+	assert.NoError(t, conf.Compile())
+	return conf
+}
+
+func TestRulesAnalyse(t *testing.T) {
+	var (
+		htmlForRules = `
+<html>
+<body>
+    <div class="product"><span class="price">10 EUR</span></div>
+    <iframe src="https://new-cdn.abc.com/video"></iframe>
+    <a href="https://xyz.com" target="_blank" rel="noopener noreferrer">xyz</a>
+    <h1>Product</h1>
+</body>
+</html>
+`
+		rulesYAML = `
+rules:
+  - name: single_price
+    selector: ".price"
+    count:
+      equals: 1
+  - name: no_old_cdn
+    selector: "iframe[src*='old-cdn']"
+    count:
+      max: 0
+  - name: safe_blank_links
+    selector: "a[target='_blank']"
+    attribute:
+      name: rel
+      contains: noopener
+  - name: priced_in_euro
+    selector: ".price"
+    text:
+      not_empty: true
+      matches: "^[0-9]+ EUR$"
+`
+	)
+	ctx := context.Background()
+
+	actual := NewRules(rulesFromYAML(t, rulesYAML)).Analyse(ctx, docFromHTML(t, htmlForRules), "https://abc.com/products/1")
+	assert.Equal(t, 4, actual.Passed)
+	assert.Equal(t, 0, actual.Failed)
+	assert.Len(t, actual.Results, 4)
+	assert.Equal(t, 1, actual.Results[0].Matched)
+	assert.Empty(t, actual.Findings)
+}
+
+func TestRulesAnalyseFailures(t *testing.T) {
+	var (
+		htmlForRuleFailures = `
+<html>
+<body>
+    <span class="price">10</span><span class="price"></span>
+    <iframe src="https://old-cdn.abc.com/video"></iframe>
+    <a href="https://xyz.com" target="_blank">xyz</a>
+</body>
+</html>
+`
+		rulesYAML = `
+rules:
+  - name: single_price
+    selector: ".price"
+    severity: error
+    count:
+      equals: 1
+  - name: no_old_cdn
+    selector: "iframe[src*='old-cdn']"
+    count:
+      max: 0
+  - name: safe_blank_links
+    selector: "a[target='_blank']"
+    attribute:
+      name: rel
+      contains: noopener
+  - name: price_text
+    selector: ".price"
+    text:
+      not_empty: true
+`
+	)
+	ctx := context.Background()
+
+	actual := NewRules(rulesFromYAML(t, rulesYAML)).Analyse(ctx, docFromHTML(t, htmlForRuleFailures), "https://abc.com/")
+	assert.Equal(t, 0, actual.Passed)
+	assert.Equal(t, 4, actual.Failed)
+	for _, result := range actual.Results {
+		assert.Equal(t, domain.RuleStatusFail, result.Status)
+	}
+	assert.Equal(t, []string{"matched 2 elements, expected 1"}, actual.Results[0].Messages)
+	assert.Equal(t, []string{"html > body > a has no attribute rel"}, actual.Results[2].Messages)
+	assert.Equal(t, []string{"html > body > span:nth-of-type(2) has no text"}, actual.Results[3].Messages)
+	assert.Len(t, actual.Findings, 4)
+	assert.Equal(t, domain.SeverityError, actual.Findings[0].Severity)
+	assert.Equal(t, domain.SeverityWarning, actual.Findings[1].Severity)
+}
+
+func TestRulesAnalyseSkipped(t *testing.T) {
+	var (
+		htmlForRuleStatus = `<html><body><p>text</p></body></html>`
+		rulesYAML         = `
+rules:
+  - name: product_price
+    url_pattern: "/products/"
+    selector: ".price"
+    count:
+      min: 1
+  - name: paragraph
+    url_pattern: "/about$"
+    selector: "p"
+    text:
+      matches: "^te"
+`
+	)
+	ctx := context.Background()
+
+	actual := NewRules(rulesFromYAML(t, rulesYAML)).Analyse(ctx, docFromHTML(t, htmlForRuleStatus), "https://abc.com/about")
+	assert.Equal(t, domain.RuleStatusSkipped, actual.Results[0].Status)
+	assert.Equal(t, domain.RuleStatusPass, actual.Results[1].Status)
+	assert.Equal(t, 1, actual.Passed)
+	assert.Equal(t, 0, actual.Failed)
+	assert.Empty(t, actual.Findings)
+}
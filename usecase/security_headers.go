@@ -0,0 +1,394 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	securityHeadersPrefix = "usecase.security_headers "
+
+	headerCSP                = "Content-Security-Policy"
+	headerHSTS               = "Strict-Transport-Security"
+	headerFrameOptions       = "X-Frame-Options"
+	headerContentTypeOptions = "X-Content-Type-Options"
+	headerReferrerPolicy     = "Referrer-Policy"
+	headerPermissionsPolicy  = "Permissions-Policy"
+	headerSetCookie          = "Set-Cookie"
+	headerCSPReportOnly      = "Content-Security-Policy-Report-Only"
+	minHSTSMaxAge            = 180 * 24 * 60 * 60
+	securityErrorPenalty     = 25
+	securityWarningPenalty   = 10
+	securityInfoPenalty      = 2
+)
+
+var (
+	auditedHeaders = []string{headerCSP, headerHSTS, headerFrameOptions, headerContentTypeOptions,
+		headerReferrerPolicy, headerPermissionsPolicy}
+	referrerPolicies = map[string]interface{}{
+		"no-referrer": nil, "no-referrer-when-downgrade": nil, "origin": nil, "origin-when-cross-origin": nil,
+		"same-origin": nil, "strict-origin": nil, "strict-origin-when-cross-origin": nil, "unsafe-url": nil,
+	}
+	// referrer policies which send the full url to other sites
+	leakyReferrerPolicies = map[string]interface{}{
+		"unsafe-url": nil, "no-referrer-when-downgrade": nil,
+	}
+	// script sources which let any site run scripts on the page
+	wildcardSources = map[string]interface{}{
+		"*": nil, "http:": nil, "https:": nil, "data:": nil,
+	}
+	securityGrades = []struct {
+		minScore int
+		grade    string
+	}{
+		{100, "A+"}, {90, "A"}, {80, "B"}, {65, "C"}, {50, "D"}, {0, "F"},
+	}
+)
+
+type SecurityHeaders interface {
+	Analyse(ctx context.Context, header http.Header, pageURL string) (securityHeaders domain.SecurityHeaders)
+}
+
+type securityHeaders struct{}
+
+// cspPolicy maps the directives of one policy to their sources
+type cspPolicy map[string][]string
+
+func (s securityHeaders) Analyse(ctx context.Context, header http.Header, pageURL string) (result domain.SecurityHeaders) {
+	log.WithContext(ctx).Info(securityHeadersPrefix, "start to audit the security headers")
+	result.Headers = make([]domain.HeaderResult, 0, len(auditedHeaders))
+	result.Cookies = make([]domain.CookieResult, 0)
+	result.Findings = make([]domain.Finding, 0)
+	report := func(name, code, severity, message string) {
+		finding := newFinding(code, severity, message)
+		finding.Header = name
+		result.Findings = append(result.Findings, finding)
+	}
+
+	page, err := url.Parse(pageURL)
+	https := err == nil && strings.EqualFold(page.Scheme, "https")
+	if !https {
+		report("", "not_https", domain.SeverityError, "the page is not served over https")
+	}
+
+	policies := parseCSP(header.Values(headerCSP))
+	s.checkCSP(header, policies, report)
+	s.checkHSTS(header, https, report)
+	s.checkFrameOptions(header, policies, report)
+	s.checkContentTypeOptions(header, report)
+	s.checkReferrerPolicy(header, report)
+	if header.Get(headerPermissionsPolicy) == "" {
+		report(headerPermissionsPolicy, "permissions_policy_missing", domain.SeverityInfo,
+			"Permissions-Policy is missing, the browser features the page may use are not limited")
+	}
+	result.Cookies = s.checkCookies(header, https, report)
+
+	for _, name := range auditedHeaders {
+		values := header.Values(name)
+		headerResult := domain.HeaderResult{
+			Name:    name,
+			Value:   strings.Join(values, ", "),
+			Present: len(values) > 0,
+			Passed:  true,
+		}
+		for _, finding := range result.Findings {
+			if finding.Header == name && finding.Severity != domain.SeverityInfo {
+				headerResult.Passed = false
+			}
+		}
+		result.Headers = append(result.Headers, headerResult)
+	}
+	result.Score, result.Grade = securityGrade(result.Findings)
+	return result
+}
+
+func NewSecurityHeaders() SecurityHeaders {
+	return &securityHeaders{}
+}
+
+func (s securityHeaders) checkCSP(header http.Header, policies []cspPolicy, report func(name, code, severity, message string)) {
+	if len(policies) == 0 {
+		if header.Get(headerCSPReportOnly) != "" {
+			report(headerCSP, "csp_report_only", domain.SeverityWarning,
+				"Content-Security-Policy is only reported, it is not enforced")
+			return
+		}
+		report(headerCSP, "csp_missing", domain.SeverityError, "Content-Security-Policy is missing")
+		return
+	}
+
+	// every policy is enforced, so a source is only allowed when all the policies allow it
+	scriptPolicies := make([][]string, 0)
+	for _, policy := range policies {
+		if sources, ok := scriptSources(policy); ok {
+			scriptPolicies = append(scriptPolicies, sources)
+		}
+	}
+	if len(scriptPolicies) == 0 {
+		report(headerCSP, "csp_no_script_restriction", domain.SeverityWarning,
+			"Content-Security-Policy has neither script-src nor default-src, scripts are not restricted")
+		return
+	}
+	allAllow := func(allows func(sources []string) bool) bool {
+		for _, sources := range scriptPolicies {
+			if !allows(sources) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// a nonce or a hash makes the browser ignore 'unsafe-inline'
+	if allAllow(func(sources []string) bool {
+		return hasSource(sources, "'unsafe-inline'") && !hasSourcePrefix(sources, "'nonce-", "'sha256-", "'sha384-", "'sha512-")
+	}) {
+		report(headerCSP, "csp_unsafe_inline", domain.SeverityWarning,
+			"Content-Security-Policy allows inline scripts with 'unsafe-inline'")
+	}
+	if allAllow(func(sources []string) bool { return hasSource(sources, "'unsafe-eval'") }) {
+		report(headerCSP, "csp_unsafe_eval", domain.SeverityWarning,
+			"Content-Security-Policy allows eval with 'unsafe-eval'")
+	}
+	if allAllow(func(sources []string) bool {
+		for _, source := range sources {
+			if _, ok := wildcardSources[source]; ok {
+				return true
+			}
+		}
+		return false
+	}) {
+		report(headerCSP, "csp_wildcard_source", domain.SeverityWarning,
+			"Content-Security-Policy allows scripts from any host")
+	}
+}
+
+func (s securityHeaders) checkHSTS(header http.Header, https bool, report func(name, code, severity, message string)) {
+	value := header.Get(headerHSTS)
+	switch {
+	case !https:
+		// browsers ignore the header on plain http, not_https already covers the page
+		return
+	case value == "":
+		report(headerHSTS, "hsts_missing", domain.SeverityError, "Strict-Transport-Security is missing")
+		return
+	}
+
+	maxAge := -1
+	includeSubDomains := false
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if age, err := strconv.Atoi(strings.Trim(strings.TrimSpace(arg), `"`)); err == nil {
+				maxAge = age
+			}
+		case "includesubdomains":
+			includeSubDomains = true
+		}
+	}
+	switch {
+	case maxAge < 0:
+		report(headerHSTS, "hsts_invalid", domain.SeverityError, "Strict-Transport-Security has no valid max-age")
+	case maxAge < minHSTSMaxAge:
+		report(headerHSTS, "hsts_short_max_age", domain.SeverityWarning,
+			fmt.Sprintf("Strict-Transport-Security max-age is %d seconds, at least %d are recommended", maxAge, minHSTSMaxAge))
+	}
+	if !includeSubDomains {
+		report(headerHSTS, "hsts_no_subdomains", domain.SeverityInfo,
+			"Strict-Transport-Security does not include the subdomains")
+	}
+}
+
+// checkFrameOptions accepts either X-Frame-Options or the frame-ancestors directive of the policy
+func (s securityHeaders) checkFrameOptions(header http.Header, policies []cspPolicy, report func(name, code, severity, message string)) {
+	for _, policy := range policies {
+		if ancestors, ok := policy["frame-ancestors"]; ok {
+			if hasSource(ancestors, "*") {
+				report(headerCSP, "frame_ancestors_wildcard", domain.SeverityWarning,
+					"frame-ancestors lets any site frame the page")
+			}
+			return
+		}
+	}
+
+	value := strings.ToUpper(strings.TrimSpace(header.Get(headerFrameOptions)))
+	switch value {
+	case "DENY", "SAMEORIGIN":
+	case "":
+		report(headerFrameOptions, "frame_protection_missing", domain.SeverityWarning,
+			"neither X-Frame-Options nor frame-ancestors is set, the page can be framed by other sites")
+	default:
+		report(headerFrameOptions, "frame_options_invalid", domain.SeverityWarning,
+			fmt.Sprintf("X-Frame-Options %q is not DENY or SAMEORIGIN", value))
+	}
+}
+
+func (s securityHeaders) checkContentTypeOptions(header http.Header, report func(name, code, severity, message string)) {
+	value := strings.TrimSpace(header.Get(headerContentTypeOptions))
+	switch {
+	case value == "":
+		report(headerContentTypeOptions, "content_type_options_missing", domain.SeverityWarning,
+			"X-Content-Type-Options is missing, browsers may sniff the content type")
+	case !strings.EqualFold(value, "nosniff"):
+		report(headerContentTypeOptions, "content_type_options_invalid", domain.SeverityWarning,
+			fmt.Sprintf("X-Content-Type-Options %q is not nosniff", value))
+	}
+}
+
+// checkReferrerPolicy follows the browsers, the last known policy of the list is used
+func (s securityHeaders) checkReferrerPolicy(header http.Header, report func(name, code, severity, message string)) {
+	values := header.Values(headerReferrerPolicy)
+	if len(values) == 0 {
+		report(headerReferrerPolicy, "referrer_policy_missing", domain.SeverityInfo,
+			"Referrer-Policy is missing, the browser default is used")
+		return
+	}
+	policy := ""
+	for _, token := range strings.Split(strings.Join(values, ","), ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if _, ok := referrerPolicies[token]; ok {
+			policy = token
+		}
+	}
+	if policy == "" {
+		report(headerReferrerPolicy, "referrer_policy_invalid", domain.SeverityWarning,
+			fmt.Sprintf("Referrer-Policy %q has no known policy", strings.Join(values, ", ")))
+		return
+	}
+	if _, ok := leakyReferrerPolicies[policy]; ok {
+		report(headerReferrerPolicy, "referrer_policy_unsafe", domain.SeverityWarning,
+			fmt.Sprintf("Referrer-Policy %s sends the full url to other sites", policy))
+	}
+}
+
+func (s securityHeaders) checkCookies(header http.Header, https bool, report func(name, code, severity, message string)) []domain.CookieResult {
+	cookies := make([]domain.CookieResult, 0)
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		cookieResult := domain.CookieResult{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		}
+		cookies = append(cookies, cookieResult)
+
+		if https && !cookie.Secure {
+			report(headerSetCookie, "cookie_not_secure", domain.SeverityWarning,
+				fmt.Sprintf("cookie %s is not Secure, it is also sent over plain http", cookie.Name))
+		}
+		if !cookie.HttpOnly {
+			report(headerSetCookie, "cookie_not_http_only", domain.SeverityWarning,
+				fmt.Sprintf("cookie %s is not HttpOnly, scripts can read it", cookie.Name))
+		}
+		switch {
+		case cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure:
+			report(headerSetCookie, "cookie_samesite_none_insecure", domain.SeverityError,
+				fmt.Sprintf("cookie %s is SameSite=None without Secure, browsers reject it", cookie.Name))
+		case cookieResult.SameSite == "":
+			report(headerSetCookie, "cookie_samesite_missing", domain.SeverityInfo,
+				fmt.Sprintf("cookie %s has no SameSite attribute", cookie.Name))
+		}
+	}
+	return cookies
+}
+
+func parseCSP(values []string) []cspPolicy {
+	policies := make([]cspPolicy, 0)
+	for _, value := range values {
+		// a header may carry several policies separated by commas
+		for _, raw := range strings.Split(value, ",") {
+			policy := make(cspPolicy)
+			for _, directive := range strings.Split(raw, ";") {
+				fields := strings.Fields(directive)
+				if len(fields) == 0 {
+					continue
+				}
+				name := strings.ToLower(fields[0])
+				// the first occurrence of a directive wins
+				if _, ok := policy[name]; ok {
+					continue
+				}
+				sources := make([]string, 0, len(fields)-1)
+				for _, source := range fields[1:] {
+					sources = append(sources, strings.ToLower(source))
+				}
+				policy[name] = sources
+			}
+			if len(policy) > 0 {
+				policies = append(policies, policy)
+			}
+		}
+	}
+	return policies
+}
+
+// scriptSources returns the sources scripts are loaded from, default-src is the fallback
+func scriptSources(policy cspPolicy) ([]string, bool) {
+	if sources, ok := policy["script-src"]; ok {
+		return sources, true
+	}
+	sources, ok := policy["default-src"]
+	return sources, ok
+}
+
+func hasSource(sources []string, source string) bool {
+	for _, existing := range sources {
+		if existing == source {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSourcePrefix(sources []string, prefixes ...string) bool {
+	for _, source := range sources {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(source, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+// securityGrade takes points off for every finding, the errors weigh the most
+func securityGrade(findings []domain.Finding) (int, string) {
+	score := 100
+	for _, finding := range findings {
+		switch finding.Severity {
+		case domain.SeverityError:
+			score -= securityErrorPenalty
+		case domain.SeverityWarning:
+			score -= securityWarningPenalty
+		case domain.SeverityInfo:
+			score -= securityInfoPenalty
+		}
+	}
+	if score < 0 {
+		score = 0
+	}
+	for _, grade := range securityGrades {
+		if score >= grade.minScore {
+			return score, grade.grade
+		}
+	}
+	return score, "F"
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"testing"
+)
+
+func TestSecurityHeadersAnalyse(t *testing.T) {
+	ctx := context.Background()
+	header := http.Header{}
+	header.Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'nonce-abc' 'unsafe-inline'; frame-ancestors 'none'")
+	header.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Referrer-Policy", "no-referrer, strict-origin-when-cross-origin")
+	header.Set("Permissions-Policy", "camera=(), geolocation=()")
+	header.Add("Set-Cookie", "session=abc; Path=/; Secure; HttpOnly; SameSite=Lax")
+
+	actual := NewSecurityHeaders().Analyse(ctx, header, "https://abc.com/")
+	assert.Empty(t, actual.Findings)
+	assert.Equal(t, 100, actual.Score)
+	assert.Equal(t, "A+", actual.Grade)
+	assert.Len(t, actual.Headers, 6)
+	for _, headerResult := range actual.Headers {
+		assert.True(t, headerResult.Passed, headerResult.Name)
+	}
+	assert.False(t, actual.Headers[2].Present)
+	assert.Equal(t, []domain.CookieResult{{Name: "session", Secure: true, HttpOnly: true, SameSite: "Lax"}}, actual.Cookies)
+}
+
+func TestSecurityHeadersAnalyseFindings(t *testing.T) {
+	ctx := context.Background()
+	header := http.Header{}
+	header.Set("Content-Security-Policy", "script-src * 'unsafe-inline' 'unsafe-eval'")
+	header.Set("Strict-Transport-Security", "max-age=3600")
+	header.Set("X-Frame-Options", "ALLOW-FROM https://xyz.com")
+	header.Set("X-Content-Type-Options", "sniff")
+	header.Set("Referrer-Policy", "unsafe-url")
+	header.Add("Set-Cookie", "session=abc; Path=/")
+	header.Add("Set-Cookie", "tracker=xyz; SameSite=None")
+
+	actual := NewSecurityHeaders().Analyse(ctx, header, "https://abc.com/")
+	assert.ElementsMatch(t, []string{
+		"csp_unsafe_inline", "csp_unsafe_eval", "csp_wildcard_source",
+		"hsts_short_max_age", "hsts_no_subdomains",
+		"frame_options_invalid", "content_type_options_invalid", "referrer_policy_unsafe",
+		"permissions_policy_missing",
+		"cookie_not_secure", "cookie_not_http_only", "cookie_samesite_missing",
+		"cookie_not_secure", "cookie_not_http_only", "cookie_samesite_none_insecure",
+	}, findingCodes(actual.Findings))
+	assert.Equal(t, 0, actual.Score)
+	assert.Equal(t, "F", actual.Grade)
+	assert.Equal(t, "Strict-Transport-Security", actual.Findings[3].Header)
+	assert.Equal(t, "None", actual.Cookies[1].SameSite)
+}
+
+func TestSecurityHeadersAnalyseMissing(t *testing.T) {
+	ctx := context.Background()
+	header := http.Header{}
+	header.Set("Content-Security-Policy-Report-Only", "default-src 'self'")
+
+	// hsts is not checked over plain http, the page itself is reported instead
+	actual := NewSecurityHeaders().Analyse(ctx, header, "http://abc.com/")
+	assert.ElementsMatch(t, []string{
+		"not_https", "csp_report_only", "frame_protection_missing", "content_type_options_missing",
+		"referrer_policy_missing", "permissions_policy_missing",
+	}, findingCodes(actual.Findings))
+	assert.Equal(t, 41, actual.Score)
+	assert.Equal(t, "F", actual.Grade)
+
+	// inline scripts are blocked as soon as one of the policies blocks them
+	header = http.Header{}
+	header.Add("Content-Security-Policy", "default-src 'self' 'unsafe-inline'")
+	header.Add("Content-Security-Policy", "script-src 'nonce-abc' 'unsafe-inline'")
+	actual = NewSecurityHeaders().Analyse(ctx, header, "https://abc.com/")
+	assert.NotContains(t, findingCodes(actual.Findings), "csp_unsafe_inline")
+	assert.Contains(t, findingCodes(actual.Findings), "hsts_missing")
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	seoPrefix = "usecase.seo "
+
+	// length ranges which are shown in full by the search engines
+	minTitleLength       = 30
+	maxTitleLength       = 60
+	minDescriptionLength = 70
+	maxDescriptionLength = 160
+)
+
+var (
+	hreflangPattern = regexp.MustCompile(`(?i)^(x-default|[a-z]{2,3}(-[a-z0-9]{2,8})*)$`)
+)
+
+type SEO interface {
+	Analyse(ctx context.Context, doc *goquery.Document) (seo domain.SEO)
+}
+
+type seo struct{}
+
+func (s seo) Analyse(ctx context.Context, doc *goquery.Document) (result domain.SEO) {
+	log.WithContext(ctx).Info(seoPrefix, "start to analyse the seo metadata")
+	result.Hreflang = make([]domain.Hreflang, 0)
+	result.Findings = make([]domain.Finding, 0)
+
+	title := strings.TrimSpace(doc.Find("title").First().Text())
+	result.TitleLength = utf8.RuneCountInString(title)
+	switch {
+	case title == "":
+		result.Findings = append(result.Findings, newFinding("title_missing", domain.SeverityError,
+			"the page has no title"))
+	case result.TitleLength < minTitleLength:
+		result.Findings = append(result.Findings, newFinding("title_too_short", domain.SeverityWarning,
+			fmt.Sprintf("the title has %d characters, at least %d are recommended", result.TitleLength, minTitleLength)))
+	case result.TitleLength > maxTitleLength:
+		result.Findings = append(result.Findings, newFinding("title_too_long", domain.SeverityWarning,
+			fmt.Sprintf("the title has %d characters, it is cut after %d", result.TitleLength, maxTitleLength)))
+	}
+
+	robots := make([]string, 0)
+	doc.Find("meta[name]").Each(func(i int, meta *goquery.Selection) {
+		name, _ := meta.Attr("name")
+		content, _ := meta.Attr("content")
+		content = strings.TrimSpace(content)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "description":
+			if result.MetaDescription == "" {
+				result.MetaDescription = content
+			}
+		case "robots", "googlebot":
+			robots = append(robots, content)
+		}
+	})
+	s.checkDescription(&result)
+	s.checkRobots(&result, robots)
+	s.checkCanonical(&result, doc)
+	s.checkHreflang(&result, doc)
+
+	result.H1Count = doc.Find("h1").Length()
+	switch {
+	case result.H1Count == 0:
+		result.Findings = append(result.Findings, newFinding("h1_missing", domain.SeverityError,
+			"the page has no h1 heading"))
+	case result.H1Count > 1:
+		result.Findings = append(result.Findings, newFinding("h1_multiple", domain.SeverityWarning,
+			fmt.Sprintf("the page has %d h1 headings, one is recommended", result.H1Count)))
+	}
+	return result
+}
+
+func NewSEO() SEO {
+	return &seo{}
+}
+
+func (s seo) checkDescription(result *domain.SEO) {
+	result.DescriptionLength = utf8.RuneCountInString(result.MetaDescription)
+	switch {
+	case result.MetaDescription == "":
+		result.Findings = append(result.Findings, newFinding("description_missing", domain.SeverityWarning,
+			"the page has no meta description"))
+	case result.DescriptionLength < minDescriptionLength:
+		result.Findings = append(result.Findings, newFinding("description_too_short", domain.SeverityWarning,
+			fmt.Sprintf("the meta description has %d characters, at least %d are recommended",
+				result.DescriptionLength, minDescriptionLength)))
+	case result.DescriptionLength > maxDescriptionLength:
+		result.Findings = append(result.Findings, newFinding("description_too_long", domain.SeverityWarning,
+			fmt.Sprintf("the meta description has %d characters, it is cut after %d",
+				result.DescriptionLength, maxDescriptionLength)))
+	}
+}
+
+func (s seo) checkRobots(result *domain.SEO, robots []string) {
+	result.Robots = strings.Join(robots, ", ")
+	for _, content := range robots {
+		for _, directive := range strings.Split(strings.ToLower(content), ",") {
+			switch strings.TrimSpace(directive) {
+			case "noindex":
+				result.NoIndex = true
+			case "nofollow":
+				result.NoFollow = true
+			case "none":
+				result.NoIndex = true
+				result.NoFollow = true
+			}
+		}
+	}
+	if result.NoIndex {
+		result.Findings = append(result.Findings, newFinding("noindex", domain.SeverityWarning,
+			"the robots meta tag keeps the page out of the search index"))
+	}
+	if result.NoFollow {
+		result.Findings = append(result.Findings, newFinding("nofollow", domain.SeverityWarning,
+			"the robots meta tag asks crawlers not to follow the links of the page"))
+	}
+}
+
+func (s seo) checkCanonical(result *domain.SEO, doc *goquery.Document) {
+	canonicals := doc.Find("link[rel~='canonical']")
+	switch canonicals.Length() {
+	case 0:
+		result.Findings = append(result.Findings, newFinding("canonical_missing", domain.SeverityInfo,
+			"the page has no canonical link"))
+		return
+	case 1:
+	default:
+		result.Findings = append(result.Findings, newFinding("canonical_multiple", domain.SeverityWarning,
+			fmt.Sprintf("the page has %d canonical links, search engines may ignore all of them", canonicals.Length())))
+	}
+
+	href, _ := canonicals.First().Attr("href")
+	result.Canonical = strings.TrimSpace(href)
+	parsed, err := url.Parse(result.Canonical)
+	switch {
+	case result.Canonical == "" || err != nil:
+		result.Findings = append(result.Findings, newFinding("canonical_invalid", domain.SeverityError,
+			"the canonical link has no valid href"))
+	case !parsed.IsAbs():
+		result.Findings = append(result.Findings, newFinding("canonical_relative", domain.SeverityInfo,
+			"the canonical link should be an absolute url"))
+	}
+}
+
+func (s seo) checkHreflang(result *domain.SEO, doc *goquery.Document) {
+	languages := make(map[string]interface{})
+	doc.Find("link[rel~='alternate'][hreflang]").Each(func(i int, alternate *goquery.Selection) {
+		lang, _ := alternate.Attr("hreflang")
+		href, _ := alternate.Attr("href")
+		lang, href = strings.TrimSpace(lang), strings.TrimSpace(href)
+		result.Hreflang = append(result.Hreflang, domain.Hreflang{Lang: lang, Url: href})
+
+		if !hreflangPattern.MatchString(lang) {
+			result.Findings = append(result.Findings, newFinding("hreflang_invalid", domain.SeverityError,
+				fmt.Sprintf("hreflang %q is not a valid language code", lang)))
+		}
+		if href == "" {
+			result.Findings = append(result.Findings, newFinding("hreflang_missing_href", domain.SeverityError,
+				fmt.Sprintf("hreflang %q has no href", lang)))
+		}
+		if _, ok := languages[strings.ToLower(lang)]; ok {
+			result.Findings = append(result.Findings, newFinding("hreflang_duplicate", domain.SeverityWarning,
+				fmt.Sprintf("hreflang %q is declared more than once", lang)))
+		}
+		languages[strings.ToLower(lang)] = nil
+	})
+	if _, ok := languages["x-default"]; len(languages) > 0 && !ok {
+		result.Findings = append(result.Findings, newFinding("hreflang_no_default", domain.SeverityInfo,
+			"the hreflang alternates have no x-default"))
+	}
+}
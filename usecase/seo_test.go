@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"testing"
+)
+
+func findingCodes(findings []domain.Finding) []string {
+	codes := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		codes = append(codes, finding.Code)
+	}
+	return codes
+}
+
+func TestSEOAnalyse(t *testing.T) {
+	var (
+		htmlForSEO = `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Web page analysis - check links, headings and more</title>
+    <meta name="description" content="Analyse any web page for broken links, heading structure, login forms and search engine metadata.">
+    <meta name="robots" content="index, follow">
+    <link rel="canonical" href="https://abc.com/">
+    <link rel="alternate" hreflang="en" href="https://abc.com/">
+    <link rel="alternate" hreflang="de-DE" href="https://abc.com/de/">
+    <link rel="alternate" hreflang="x-default" href="https://abc.com/">
+</head>
+<body><h1>Web page analysis</h1></body>
+</html>
+`
+	)
+	ctx := context.Background()
+
+	actual := NewSEO().Analyse(ctx, docFromHTML(t, htmlForSEO))
+	assert.Equal(t, "https://abc.com/", actual.Canonical)
+	assert.Equal(t, "index, follow", actual.Robots)
+	assert.False(t, actual.NoIndex)
+	assert.Len(t, actual.Hreflang, 3)
+	assert.Equal(t, 1, actual.H1Count)
+	assert.Empty(t, actual.Findings)
+}
+
+func TestSEOAnalyseFindings(t *testing.T) {
+	var (
+		htmlForSEOFindings = `
+<html>
+<head>
+    <title>Short</title>
+    <meta name="ROBOTS" content="noindex,nofollow">
+    <link rel="canonical" href="/page">
+    <link rel="alternate" hreflang="english" href="https://abc.com/">
+</head>
+<body><h1>One</h1><h1>Two</h1></body>
+</html>
+`
+	)
+	ctx := context.Background()
+
+	actual := NewSEO().Analyse(ctx, docFromHTML(t, htmlForSEOFindings))
+	assert.True(t, actual.NoIndex)
+	assert.True(t, actual.NoFollow)
+	assert.ElementsMatch(t, []string{"title_too_short", "description_missing", "noindex", "nofollow",
+		"canonical_relative", "hreflang_invalid", "hreflang_no_default", "h1_multiple"}, findingCodes(actual.Findings))
+}
+
+func TestSEOAnalyseMissing(t *testing.T) {
+	var (
+		htmlForSEOMissing = `<html><body><p>nothing here</p></body></html>`
+	)
+	ctx := context.Background()
+
+	actual := NewSEO().Analyse(ctx, docFromHTML(t, htmlForSEOMissing))
+	assert.ElementsMatch(t, []string{"title_missing", "description_missing", "canonical_missing", "h1_missing"},
+		findingCodes(actual.Findings))
+	for _, finding := range actual.Findings {
+		if finding.Code == "title_missing" {
+			assert.Equal(t, domain.SeverityError, finding.Severity)
+		}
+	}
+}
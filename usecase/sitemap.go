@@ -0,0 +1,390 @@
+package usecase
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sitemapPrefix = "usecase.sitemap "
+
+	// limits of the sitemap protocol
+	maxSitemapUrls  = 50000
+	maxSitemapBytes = 50 * 1024 * 1024
+	// sitemap files fetched at most for a site, the index entries included
+	maxSitemapFiles = 100
+)
+
+var (
+	lastmodLayouts = []string{
+		"2006", "2006-01", "2006-01-02", "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05Z07:00",
+		"2006-01-02T15:04:05.999999999Z07:00",
+	}
+	changefreqs = map[string]interface{}{
+		"always": nil, "hourly": nil, "daily": nil, "weekly": nil, "monthly": nil, "yearly": nil, "never": nil,
+	}
+)
+
+type Sitemap interface {
+	// Analyse discovers the sitemaps of the site, validates them and checks
+	// up to maxChecks of the listed urls, err is set when the site is on a blocked address
+	Analyse(ctx context.Context, siteURL string, maxChecks int) (result domain.SitemapResult, err error)
+}
+
+type sitemap struct {
+	ctr    container.Container
+	config bootstrap.Config
+}
+
+// sitemapDocument holds either a urlset or a sitemap index
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc        string `xml:"loc"`
+	Lastmod    string `xml:"lastmod"`
+	Changefreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+type sitemapLocation struct {
+	url    string
+	source string
+	depth  int
+}
+
+func (s sitemap) Analyse(ctx context.Context, siteURL string, maxChecks int) (result domain.SitemapResult, err error) {
+	log.WithContext(ctx).Info(sitemapPrefix, "start to analyse the sitemaps")
+	result.Sitemaps = make([]domain.SitemapFile, 0)
+	result.InaccessibleUrls = make([]string, 0)
+	result.Checks = make([]domain.LinkDetail, 0)
+	result.Findings = make([]domain.Finding, 0)
+
+	queue := s.discover(ctx, siteURL)
+	visited := make(map[string]interface{})
+	distinct := make(map[string]interface{})
+	urls := make([]string, 0)
+	parsedURLSet := false
+	for len(queue) > 0 && len(result.Sitemaps) < maxSitemapFiles {
+		location := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[location.url]; ok {
+			continue
+		}
+		visited[location.url] = nil
+
+		file, doc, fetchErr := s.fetch(ctx, location)
+		// a blocked sitemap of the site itself means the site is blocked, one on another host is only reported
+		if errors.Is(fetchErr, container.ErrDisallowedAddress) && sameHost(location.url, siteURL) {
+			return result, fetchErr
+		}
+		switch file.Type {
+		case domain.SitemapTypeIndex:
+			if location.depth > 0 {
+				file.Findings = append(file.Findings, newFinding("sitemap_nested_index", domain.SeverityWarning,
+					"a sitemap index lists another sitemap index"))
+			}
+			for _, entry := range doc.Sitemaps {
+				if loc := strings.TrimSpace(entry.Loc); loc != "" {
+					queue = append(queue, sitemapLocation{url: loc, source: domain.SitemapSourceIndex, depth: location.depth + 1})
+				}
+			}
+		case domain.SitemapTypeURLSet:
+			parsedURLSet = true
+			for _, entry := range doc.URLs {
+				loc := strings.TrimSpace(entry.Loc)
+				if _, ok := distinct[loc]; ok || loc == "" {
+					continue
+				}
+				distinct[loc] = nil
+				urls = append(urls, loc)
+			}
+		}
+		result.Sitemaps = append(result.Sitemaps, file)
+	}
+	if len(queue) > 0 {
+		result.Findings = append(result.Findings, newFinding("sitemap_files_limit", domain.SeverityInfo,
+			fmt.Sprintf("only the first %d sitemap files are read", maxSitemapFiles)))
+	}
+	if !parsedURLSet {
+		result.Findings = append(result.Findings, newFinding("sitemap_missing", domain.SeverityWarning,
+			"no sitemap listing urls was found"))
+	}
+
+	result.TotalUrls = len(urls)
+	if len(urls) > maxChecks {
+		result.Findings = append(result.Findings, newFinding("sitemap_checks_limited", domain.SeverityInfo,
+			fmt.Sprintf("%d of %d urls are checked", maxChecks, len(urls))))
+		urls = urls[:maxChecks]
+	}
+	result.Checks = s.checkURLs(ctx, siteURL, urls)
+	result.CheckedUrls = len(result.Checks)
+	for _, detail := range result.Checks {
+		if !detail.Accessible && !detail.Skipped {
+			result.InaccessibleUrls = append(result.InaccessibleUrls, detail.Url)
+		}
+	}
+	return result, nil
+}
+
+func NewSitemap(ctr container.Container, config bootstrap.Config) Sitemap {
+	return &sitemap{
+		ctr:    ctr,
+		config: config,
+	}
+}
+
+// discover returns the sitemaps listed in robots.txt, /sitemap.xml when there is none.
+// A url pointing to an xml file is taken as the sitemap itself
+func (s sitemap) discover(ctx context.Context, siteURL string) []sitemapLocation {
+	site, err := url.Parse(siteURL)
+	if err != nil {
+		return nil
+	}
+	path := strings.ToLower(site.Path)
+	if strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz") {
+		return []sitemapLocation{{url: siteURL, source: domain.SitemapSourceRequest}}
+	}
+
+	locations := make([]sitemapLocation, 0)
+	for _, loc := range NewRobots(s.ctr, s.config).Fetch(ctx, siteURL).Sitemaps {
+		locations = append(locations, sitemapLocation{url: loc, source: domain.SitemapSourceRobots})
+	}
+	if len(locations) == 0 {
+		origin := url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/sitemap.xml"}
+		locations = append(locations, sitemapLocation{url: origin.String(), source: domain.SitemapSourceDefault})
+	}
+	return locations
+}
+
+// fetch reads and validates one sitemap, gzip files are recognised by their content,
+// err is set when the sitemap could not be fetched at all
+func (s sitemap) fetch(ctx context.Context, location sitemapLocation) (file domain.SitemapFile, doc sitemapDocument, err error) {
+	file = domain.SitemapFile{
+		Url:      location.url,
+		Source:   location.source,
+		Findings: make([]domain.Finding, 0),
+	}
+	resp, err := s.ctr.OBAdapter.Get(ctx, location.url)
+	if err != nil {
+		log.WithContext(ctx).Error(sitemapPrefix, "Error in fetching sitemap: ", location.url, " err: ", err)
+		file.Error = err.Error()
+		return file, doc, err
+	}
+	defer resp.Body.Close()
+	file.StatusCode = resp.StatusCode
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		file.Error = fmt.Sprintf("status %d", resp.StatusCode)
+		return file, doc, nil
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var body io.Reader = reader
+	if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Error = err.Error()
+			return file, doc, nil
+		}
+		defer gz.Close()
+		file.Compressed = true
+		body = gz
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxSitemapBytes+1))
+	if err != nil {
+		file.Error = err.Error()
+		return file, doc, nil
+	}
+	if len(data) > maxSitemapBytes {
+		file.Error = "sitemap is over the size limit"
+		file.Findings = append(file.Findings, newFinding("sitemap_too_large", domain.SeverityError,
+			fmt.Sprintf("the sitemap is over %d bytes uncompressed", maxSitemapBytes)))
+		return file, doc, nil
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		file.Error = err.Error()
+		file.Findings = append(file.Findings, newFinding("sitemap_invalid_xml", domain.SeverityError,
+			fmt.Sprintf("the sitemap is not valid xml: %v", err)))
+		return file, doc, nil
+	}
+
+	switch doc.XMLName.Local {
+	case domain.SitemapTypeURLSet:
+		file.Type = domain.SitemapTypeURLSet
+		file.UrlCount = len(doc.URLs)
+		file.Findings = append(file.Findings, validateSitemapEntries(location.url, doc.URLs)...)
+	case domain.SitemapTypeIndex:
+		file.Type = domain.SitemapTypeIndex
+		file.UrlCount = len(doc.Sitemaps)
+		file.Findings = append(file.Findings, validateSitemapEntries(location.url, doc.Sitemaps)...)
+	default:
+		file.Error = fmt.Sprintf("unknown root element %s", doc.XMLName.Local)
+		file.Findings = append(file.Findings, newFinding("sitemap_invalid_root", domain.SeverityError,
+			fmt.Sprintf("the root element is %s, urlset or sitemapindex is expected", doc.XMLName.Local)))
+		return file, doc, nil
+	}
+	if file.UrlCount > maxSitemapUrls {
+		file.Findings = append(file.Findings, newFinding("sitemap_too_many_urls", domain.SeverityError,
+			fmt.Sprintf("the sitemap lists %d entries, at most %d are allowed", file.UrlCount, maxSitemapUrls)))
+	}
+	return file, doc, nil
+}
+
+// validateSitemapEntries reports one finding per kind of problem,
+// with the number of entries having it and the first of them
+func validateSitemapEntries(sitemapURL string, entries []sitemapEntry) []domain.Finding {
+	type issue struct {
+		severity string
+		count    int
+		example  string
+		message  string
+	}
+	issues := make(map[string]*issue)
+	order := make([]string, 0)
+	report := func(code, severity, message, example string) {
+		if _, ok := issues[code]; !ok {
+			issues[code] = &issue{severity: severity, example: example, message: message}
+			order = append(order, code)
+		}
+		issues[code].count++
+	}
+
+	sitemapHost := ""
+	if parsed, err := url.Parse(sitemapURL); err == nil {
+		sitemapHost = strings.ToLower(parsed.Hostname())
+	}
+	seen := make(map[string]interface{})
+	for _, entry := range entries {
+		loc := strings.TrimSpace(entry.Loc)
+		parsed, err := url.Parse(loc)
+		switch {
+		case loc == "" || err != nil || !parsed.IsAbs():
+			report("sitemap_invalid_loc", domain.SeverityError, "have no valid absolute loc", loc)
+		case !strings.EqualFold(parsed.Hostname(), sitemapHost):
+			report("sitemap_cross_host", domain.SeverityWarning, "are on another host than the sitemap", loc)
+		}
+		if _, ok := seen[loc]; ok {
+			report("sitemap_duplicate_loc", domain.SeverityInfo, "are listed more than once", loc)
+		}
+		seen[loc] = nil
+
+		if lastmod := strings.TrimSpace(entry.Lastmod); lastmod != "" && !isValidLastmod(lastmod) {
+			report("sitemap_invalid_lastmod", domain.SeverityWarning, "have a lastmod which is not a W3C datetime", lastmod)
+		}
+		if priority := strings.TrimSpace(entry.Priority); priority != "" {
+			value, err := strconv.ParseFloat(priority, 64)
+			if err != nil || value < 0 || value > 1 {
+				report("sitemap_invalid_priority", domain.SeverityWarning, "have a priority outside 0.0 to 1.0", priority)
+			}
+		}
+		if changefreq := strings.TrimSpace(entry.Changefreq); changefreq != "" {
+			if _, ok := changefreqs[strings.ToLower(changefreq)]; !ok {
+				report("sitemap_invalid_changefreq", domain.SeverityWarning, "have an unknown changefreq", changefreq)
+			}
+		}
+	}
+
+	findings := make([]domain.Finding, 0, len(order))
+	for _, code := range order {
+		found := issues[code]
+		findings = append(findings, newFinding(code, found.severity,
+			fmt.Sprintf("%d entries %s, like %q", found.count, found.message, found.example)))
+	}
+	return findings
+}
+
+func isValidLastmod(lastmod string) bool {
+	for _, layout := range lastmodLayouts {
+		if _, err := time.Parse(layout, lastmod); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURLs checks the listed urls with a worker pool, the same way the links of a page are checked
+func (s sitemap) checkURLs(ctx context.Context, siteURL string, urls []string) []domain.LinkDetail {
+	var (
+		checker   = analyser{ctr: s.ctr, config: s.config}
+		robotsObj = NewRobots(s.ctr, s.config)
+		conf      = s.config.AppConfig.Robots
+		jobs      = make(chan int)
+		details   = make([]domain.LinkDetail, len(urls))
+		wg        sync.WaitGroup
+	)
+	resolver, err := newLinkResolver(siteURL, nil, s.config.AppConfig.LinkClassification)
+	if err != nil {
+		log.WithContext(ctx).Error(sitemapPrefix, "invalid site url, err: ", err)
+		return details[:0]
+	}
+	progress := Progress(ctx)
+
+	workers := int(s.config.AppConfig.WorkerCount)
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				link := urls[index]
+				disallowed, robotsUnreachable := robotsCheck(ctx, robotsObj, conf, link)
+				detail := domain.LinkDetail{Url: link, RobotsDisallowed: disallowed, Skipped: disallowed && conf.Respect}
+				if !detail.Skipped {
+					detail = checker.checkLink(ctx, link)
+					detail.RobotsDisallowed = disallowed
+				}
+				detail.RobotsUnreachable = robotsUnreachable
+				if _, linkType, err := resolver.resolve(link); err == nil {
+					detail.Type = linkType
+					detail.Internal = linkType == domain.LinkTypeInternal
+				}
+				// each worker writes its own index, no lock is needed
+				details[index] = detail
+				progress.LinkChecked(domain.LinkCheck{
+					Url:        detail.Url,
+					StatusCode: detail.StatusCode,
+					LatencyMs:  detail.ResponseTimeMs,
+					Accessible: detail.Accessible,
+					Error:      detail.Error,
+				})
+			}
+		}()
+	}
+	for index, link := range urls {
+		progress.LinkFound(link)
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+	return details
+}
+
+// sameHost tells whether the two urls are on the same host
+func sameHost(first, second string) bool {
+	firstURL, err := url.Parse(first)
+	if err != nil {
+		return false
+	}
+	secondURL, err := url.Parse(second)
+	return err == nil && strings.EqualFold(firstURL.Hostname(), secondURL.Hostname())
+}
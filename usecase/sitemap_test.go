@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func sitemapServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User-agent: *\nDisallow:\nSitemap: %s/sitemap_index.xml\n", server.URL)
+	})
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>%[1]s/sitemap-pages.xml</loc><lastmod>2024-05-01</lastmod></sitemap>
+  <sitemap><loc>%[1]s/sitemap-posts.xml.gz</loc></sitemap>
+</sitemapindex>`, server.URL)
+	})
+	mux.HandleFunc("/sitemap-pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%[1]s/</loc><lastmod>2024-05-01T10:00:00+00:00</lastmod><priority>1.0</priority><changefreq>daily</changefreq></url>
+  <url><loc>%[1]s/about</loc><lastmod>01/05/2024</lastmod><priority>1.5</priority></url>
+  <url><loc>%[1]s/about</loc><changefreq>sometimes</changefreq></url>
+</urlset>`, server.URL)
+	})
+	mux.HandleFunc("/sitemap-posts.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		fmt.Fprintf(gz, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%[1]s/posts/1</loc><lastmod>2024-04</lastmod></url>
+  <url><loc>%[1]s/posts/gone</loc></url>
+</urlset>`, server.URL)
+		assert.NoError(t, gz.Close())
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(buf.Bytes())
+	})
+	mux.HandleFunc("/posts/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func TestSitemapAnalyse(t *testing.T) {
+	server := sitemapServer(t)
+	defer server.Close()
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{WorkerCount: 2}}
+	ctr := container.Container{OBAdapter: outboundForTest(10)}
+
+	actual, err := NewSitemap(ctr, conf).Analyse(ctx, server.URL, 10)
+	assert.NoError(t, err)
+	assert.Len(t, actual.Sitemaps, 3)
+	assert.Equal(t, domain.SitemapSourceRobots, actual.Sitemaps[0].Source)
+	assert.Equal(t, domain.SitemapTypeIndex, actual.Sitemaps[0].Type)
+	assert.Equal(t, 2, actual.Sitemaps[0].UrlCount)
+	assert.Empty(t, actual.Sitemaps[0].Findings)
+
+	pages := actual.Sitemaps[1]
+	assert.Equal(t, domain.SitemapSourceIndex, pages.Source)
+	assert.Equal(t, 3, pages.UrlCount)
+	assert.False(t, pages.Compressed)
+	assert.Equal(t, []string{"sitemap_invalid_lastmod", "sitemap_invalid_priority", "sitemap_duplicate_loc",
+		"sitemap_invalid_changefreq"}, findingCodes(pages.Findings))
+
+	posts := actual.Sitemaps[2]
+	assert.True(t, posts.Compressed)
+	assert.Equal(t, domain.SitemapTypeURLSet, posts.Type)
+	assert.Empty(t, posts.Findings)
+
+	assert.Equal(t, 4, actual.TotalUrls)
+	assert.Equal(t, 4, actual.CheckedUrls)
+	assert.Equal(t, []string{server.URL + "/posts/gone"}, actual.InaccessibleUrls)
+	assert.Equal(t, domain.LinkTypeInternal, actual.Checks[0].Type)
+	assert.True(t, actual.Checks[0].Internal)
+	assert.Empty(t, actual.Findings)
+}
+
+func TestSitemapAnalyseLimitsChecks(t *testing.T) {
+	server := sitemapServer(t)
+	defer server.Close()
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{WorkerCount: 2}}
+	ctr := container.Container{OBAdapter: outboundForTest(10)}
+
+	// a sitemap url is read on its own, without looking at robots.txt
+	actual, err := NewSitemap(ctr, conf).Analyse(ctx, server.URL+"/sitemap-posts.xml.gz", 1)
+	assert.NoError(t, err)
+	assert.Len(t, actual.Sitemaps, 1)
+	assert.Equal(t, domain.SitemapSourceRequest, actual.Sitemaps[0].Source)
+	assert.Equal(t, 2, actual.TotalUrls)
+	assert.Equal(t, 1, actual.CheckedUrls)
+	assert.Equal(t, []string{"sitemap_checks_limited"}, findingCodes(actual.Findings))
+}
+
+func TestSitemapAnalyseMissing(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	ctx := context.Background()
+	conf := bootstrap.Config{AppConfig: bootstrap.AppConfig{WorkerCount: 2}}
+	ctr := container.Container{OBAdapter: outboundForTest(10)}
+
+	actual, err := NewSitemap(ctr, conf).Analyse(ctx, server.URL, 10)
+	assert.NoError(t, err)
+	assert.Len(t, actual.Sitemaps, 1)
+	assert.Equal(t, domain.SitemapSourceDefault, actual.Sitemaps[0].Source)
+	assert.Equal(t, server.URL+"/sitemap.xml", actual.Sitemaps[0].Url)
+	assert.Equal(t, "status 404", actual.Sitemaps[0].Error)
+	assert.Equal(t, []string{"sitemap_missing"}, findingCodes(actual.Findings))
+}
+
+func TestSitemapAnalyseBlockedSite(t *testing.T) {
+	server := sitemapServer(t)
+	defer server.Close()
+	conf := bootstrap.Config{
+		AppConfig:    bootstrap.AppConfig{WorkerCount: 2},
+		OutboundConf: bootstrap.OutboundConfig{DialTimeout: 1000, BlockPrivateNetworks: true},
+	}
+	ctr := container.Container{OBAdapter: container.InitOutBoundConnection(conf)}
+
+	_, err := NewSitemap(ctr, conf).Analyse(context.Background(), server.URL, 10)
+	assert.True(t, errors.Is(err, container.ErrDisallowedAddress))
+	_, err = NewSitemap(ctr, conf).Analyse(context.Background(), server.URL+"/sitemap-posts.xml.gz", 10)
+	assert.True(t, errors.Is(err, container.ErrDisallowedAddress))
+}
+
+func TestValidateSitemapEntries(t *testing.T) {
+	entries := []sitemapEntry{
+		{Loc: "https://abc.com/a", Lastmod: "2024-05-01T10:00Z", Priority: "0.5"},
+		{Loc: "/relative"},
+		{Loc: "https://xyz.com/b"},
+		{Loc: "https://abc.com/c", Lastmod: "2024-13-01"},
+		{Loc: "https://abc.com/d", Lastmod: "yesterday", Priority: "high"},
+	}
+
+	actual := validateSitemapEntries("https://abc.com/sitemap.xml", entries)
+	assert.Equal(t, []string{"sitemap_invalid_loc", "sitemap_cross_host", "sitemap_invalid_lastmod",
+		"sitemap_invalid_priority"}, findingCodes(actual))
+	assert.True(t, strings.HasPrefix(actual[2].Message, "2 entries"))
+}
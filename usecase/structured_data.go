@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"strings"
+)
+
+const (
+	structuredDataPrefix = "usecase.structured_data "
+)
+
+var (
+	requiredOpenGraph = []string{"og:title", "og:type", "og:image", "og:url"}
+	twitterCardTypes  = map[string]interface{}{
+		"summary": nil, "summary_large_image": nil, "app": nil, "player": nil,
+	}
+	// schema types sharing the article requirements
+	articleTypes = map[string]interface{}{
+		"Article": nil, "NewsArticle": nil, "BlogPosting": nil, "TechArticle": nil,
+	}
+)
+
+type StructuredData interface {
+	Analyse(ctx context.Context, doc *goquery.Document) (data domain.StructuredData)
+}
+
+type structuredData struct{}
+
+func (s structuredData) Analyse(ctx context.Context, doc *goquery.Document) (data domain.StructuredData) {
+	log.WithContext(ctx).Info(structuredDataPrefix, "start to extract the structured data")
+	data = domain.StructuredData{
+		OpenGraph:   make(map[string][]string),
+		TwitterCard: make(map[string]string),
+		JSONLD:      make([]domain.StructuredItem, 0),
+		Microdata:   make([]domain.StructuredItem, 0),
+		ParseErrors: make([]domain.StructuredParseError, 0),
+		Findings:    make([]domain.Finding, 0),
+	}
+
+	s.extractMetaTags(doc, &data)
+	s.extractJSONLD(doc, &data)
+	s.extractMicrodata(doc, &data)
+
+	s.validateSocialTags(&data)
+	for _, item := range data.JSONLD {
+		data.Findings = append(data.Findings, validateSchemaItem(domain.StructuredSourceJSONLD, item)...)
+	}
+	for _, item := range data.Microdata {
+		data.Findings = append(data.Findings, validateSchemaItem(domain.StructuredSourceMicrodata, item)...)
+	}
+	return data
+}
+
+func NewStructuredData() StructuredData {
+	return &structuredData{}
+}
+
+// extractMetaTags reads the open graph and the twitter card tags,
+// sites use both the property and the name attribute for either of them
+func (s structuredData) extractMetaTags(doc *goquery.Document, data *domain.StructuredData) {
+	doc.Find("meta").Each(func(i int, meta *goquery.Selection) {
+		key, ok := meta.Attr("property")
+		if !ok {
+			key, _ = meta.Attr("name")
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		content, _ := meta.Attr("content")
+		content = strings.TrimSpace(content)
+		switch {
+		case strings.HasPrefix(key, "og:"):
+			data.OpenGraph[key] = append(data.OpenGraph[key], content)
+		case strings.HasPrefix(key, "twitter:"):
+			if _, exists := data.TwitterCard[key]; !exists {
+				data.TwitterCard[key] = content
+			}
+		}
+	})
+}
+
+func (s structuredData) extractJSONLD(doc *goquery.Document, data *domain.StructuredData) {
+	doc.Find("script[type='application/ld+json']").Each(func(i int, script *goquery.Selection) {
+		var block interface{}
+		err := json.Unmarshal([]byte(strings.TrimSpace(script.Text())), &block)
+		if err != nil {
+			data.ParseErrors = append(data.ParseErrors, domain.StructuredParseError{
+				Source:  domain.StructuredSourceJSONLD,
+				Index:   i,
+				Message: err.Error(),
+			})
+			return
+		}
+		data.JSONLD = append(data.JSONLD, jsonLDItems(block)...)
+	})
+}
+
+// jsonLDItems flattens a json-ld block, which may be a single object,
+// an array of objects or an object holding a @graph
+func jsonLDItems(block interface{}) []domain.StructuredItem {
+	items := make([]domain.StructuredItem, 0)
+	switch value := block.(type) {
+	case []interface{}:
+		for _, element := range value {
+			items = append(items, jsonLDItems(element)...)
+		}
+	case map[string]interface{}:
+		if graph, ok := value["@graph"]; ok {
+			items = append(items, jsonLDItems(graph)...)
+		}
+		if _, ok := value["@type"]; ok {
+			items = append(items, domain.StructuredItem{Type: schemaType(value["@type"]), Properties: value})
+		}
+	}
+	return items
+}
+
+// extractMicrodata reads the top level items, the nested ones
+// are kept as the property values of their parent
+func (s structuredData) extractMicrodata(doc *goquery.Document, data *domain.StructuredData) {
+	doc.Find("[itemscope]").Each(func(i int, scope *goquery.Selection) {
+		if _, nested := scope.Attr("itemprop"); nested {
+			return
+		}
+		item := microdataItem(scope)
+		if item.Type == "" {
+			data.ParseErrors = append(data.ParseErrors, domain.StructuredParseError{
+				Source:  domain.StructuredSourceMicrodata,
+				Index:   i,
+				Message: "itemscope without itemtype",
+			})
+		}
+		data.Microdata = append(data.Microdata, item)
+	})
+}
+
+func microdataItem(scope *goquery.Selection) domain.StructuredItem {
+	itemType, _ := scope.Attr("itemtype")
+	item := domain.StructuredItem{
+		Type:       schemaType(strings.TrimSpace(itemType)),
+		Properties: make(map[string]interface{}),
+	}
+	scope.Find("[itemprop]").Each(func(i int, prop *goquery.Selection) {
+		// properties of a nested item belong to that item
+		owner := prop.Parent().Closest("[itemscope]")
+		if owner.Length() == 0 || owner.Get(0) != scope.Get(0) {
+			return
+		}
+		var value interface{}
+		if _, ok := prop.Attr("itemscope"); ok {
+			nested := microdataItem(prop)
+			nested.Properties["@type"] = nested.Type
+			value = nested.Properties
+		} else {
+			value = microdataValue(prop)
+		}
+		names, _ := prop.Attr("itemprop")
+		for _, name := range strings.Fields(names) {
+			switch existing := item.Properties[name].(type) {
+			case nil:
+				item.Properties[name] = value
+			case []interface{}:
+				item.Properties[name] = append(existing, value)
+			default:
+				item.Properties[name] = []interface{}{existing, value}
+			}
+		}
+	})
+	return item
+}
+
+func microdataValue(prop *goquery.Selection) string {
+	for _, attr := range []string{"content", "href", "src", "datetime", "value"} {
+		if value, ok := prop.Attr(attr); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return strings.Join(strings.Fields(prop.Text()), " ")
+}
+
+func (s structuredData) validateSocialTags(data *domain.StructuredData) {
+	if len(data.OpenGraph) == 0 {
+		data.Findings = append(data.Findings, newFinding("og_missing", domain.SeverityWarning,
+			"the page has no open graph tags, social previews fall back to guesses"))
+	} else {
+		for _, property := range requiredOpenGraph {
+			if len(data.OpenGraph[property]) == 0 || data.OpenGraph[property][0] == "" {
+				data.Findings = append(data.Findings, newFinding("og_required_missing", domain.SeverityWarning,
+					fmt.Sprintf("open graph property %s is missing", property)))
+			}
+		}
+	}
+
+	card, ok := data.TwitterCard["twitter:card"]
+	switch {
+	case len(data.TwitterCard) == 0:
+		data.Findings = append(data.Findings, newFinding("twitter_card_missing", domain.SeverityInfo,
+			"the page has no twitter card tags"))
+	case !ok:
+		data.Findings = append(data.Findings, newFinding("twitter_card_type_missing", domain.SeverityWarning,
+			"twitter:card is missing"))
+	default:
+		if _, valid := twitterCardTypes[card]; !valid {
+			data.Findings = append(data.Findings, newFinding("twitter_card_type_invalid", domain.SeverityWarning,
+				fmt.Sprintf("twitter:card %q is not a known card type", card)))
+		}
+	}
+}
+
+// validateSchemaItem checks the properties required for rich results
+// of the common schema.org types
+func validateSchemaItem(source string, item domain.StructuredItem) []domain.Finding {
+	findings := make([]domain.Finding, 0)
+	require := func(properties ...string) {
+		for _, property := range properties {
+			if !hasProperty(item.Properties, property) {
+				findings = append(findings, newFinding("schema_required_missing", domain.SeverityError,
+					fmt.Sprintf("%s %s is missing the required property %s", source, item.Type, property)))
+			}
+		}
+	}
+
+	switch {
+	case isArticleType(item.Type):
+		require("headline", "author", "datePublished")
+	case item.Type == "Product":
+		require("name")
+		if !hasProperty(item.Properties, "offers") && !hasProperty(item.Properties, "review") &&
+			!hasProperty(item.Properties, "aggregateRating") {
+			findings = append(findings, newFinding("schema_required_missing", domain.SeverityError,
+				fmt.Sprintf("%s Product needs one of offers, review or aggregateRating", source)))
+		}
+	case item.Type == "Organization":
+		require("name", "url")
+	case item.Type == "BreadcrumbList":
+		require("itemListElement")
+		for i, element := range asList(item.Properties["itemListElement"]) {
+			listItem, ok := element.(map[string]interface{})
+			if !ok || !hasProperty(listItem, "position") ||
+				!hasProperty(listItem, "name") && !hasNestedName(listItem["item"]) {
+				findings = append(findings, newFinding("schema_required_missing", domain.SeverityError,
+					fmt.Sprintf("%s BreadcrumbList element %d needs a position and a name", source, i+1)))
+			}
+		}
+	}
+	return findings
+}
+
+func isArticleType(itemType string) bool {
+	_, ok := articleTypes[itemType]
+	return ok
+}
+
+func hasProperty(properties map[string]interface{}, name string) bool {
+	switch value := properties[name].(type) {
+	case nil:
+		return false
+	case string:
+		return strings.TrimSpace(value) != ""
+	case []interface{}:
+		return len(value) > 0
+	default:
+		return true
+	}
+}
+
+func hasNestedName(item interface{}) bool {
+	nested, ok := item.(map[string]interface{})
+	return ok && hasProperty(nested, "name")
+}
+
+func asList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	if value == nil {
+		return nil
+	}
+	return []interface{}{value}
+}
+
+// schemaType turns "https://schema.org/Product" or ["Product"] into "Product"
+func schemaType(value interface{}) string {
+	var itemType string
+	switch typed := value.(type) {
+	case string:
+		itemType = typed
+	case []interface{}:
+		if len(typed) > 0 {
+			itemType, _ = typed[0].(string)
+		}
+	}
+	itemType = strings.TrimSuffix(itemType, "/")
+	if i := strings.LastIndexAny(itemType, "/#"); i >= 0 {
+		itemType = itemType[i+1:]
+	}
+	return itemType
+}
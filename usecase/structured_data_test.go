@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"testing"
+)
+
+func TestStructuredDataSocialTags(t *testing.T) {
+	var (
+		htmlForSocialTags = `
+<html>
+<head>
+    <meta property="og:title" content="Product page">
+    <meta property="og:type" content="website">
+    <meta property="og:image" content="https://abc.com/1.png">
+    <meta property="og:image" content="https://abc.com/2.png">
+    <meta property="og:url" content="https://abc.com/">
+    <meta name="twitter:card" content="summary_large_image">
+    <meta name="twitter:site" content="@abc">
+</head>
+<body></body>
+</html>
+`
+	)
+	ctx := context.Background()
+
+	actual := NewStructuredData().Analyse(ctx, docFromHTML(t, htmlForSocialTags))
+	assert.Equal(t, []string{"https://abc.com/1.png", "https://abc.com/2.png"}, actual.OpenGraph["og:image"])
+	assert.Equal(t, "summary_large_image", actual.TwitterCard["twitter:card"])
+	assert.Empty(t, actual.Findings)
+}
+
+func TestStructuredDataJSONLD(t *testing.T) {
+	var (
+		htmlForJSONLD = `
+<html>
+<head>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+    {"@type": "Organization", "name": "ABC", "url": "https://abc.com"},
+    {"@type": "BreadcrumbList", "itemListElement": [
+        {"@type": "ListItem", "position": 1, "name": "Home", "item": "https://abc.com/"},
+        {"@type": "ListItem", "name": "Shoes"}
+    ]}
+]}
+</script>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@type": "Product", "name": "Shoe"}
+</script>
+<script type="application/ld+json">{"@type": "Article", </script>
+</head>
+<body></body>
+</html>
+`
+	)
+	ctx := context.Background()
+
+	actual := NewStructuredData().Analyse(ctx, docFromHTML(t, htmlForJSONLD))
+	assert.Len(t, actual.JSONLD, 3)
+	assert.Equal(t, "Organization", actual.JSONLD[0].Type)
+	assert.Equal(t, "Product", actual.JSONLD[2].Type)
+	assert.Len(t, actual.ParseErrors, 1)
+	assert.Equal(t, domain.StructuredSourceJSONLD, actual.ParseErrors[0].Source)
+	assert.Equal(t, 2, actual.ParseErrors[0].Index)
+
+	schemaFindings := make([]string, 0)
+	for _, finding := range actual.Findings {
+		if finding.Code == "schema_required_missing" {
+			schemaFindings = append(schemaFindings, finding.Message)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"json_ld BreadcrumbList element 2 needs a position and a name",
+		"json_ld Product needs one of offers, review or aggregateRating",
+	}, schemaFindings)
+}
+
+func TestStructuredDataMicrodata(t *testing.T) {
+	var (
+		htmlForMicrodata = `
+<html>
+<body>
+<div itemscope itemtype="https://schema.org/Article">
+    <h1 itemprop="headline">Release notes</h1>
+    <span itemprop="author" itemscope itemtype="https://schema.org/Person">
+        <span itemprop="name">Jane</span>
+    </span>
+    <meta itemprop="keywords" content="go">
+    <meta itemprop="keywords" content="html">
+</div>
+</body>
+</html>
+`
+	)
+	ctx := context.Background()
+
+	actual := NewStructuredData().Analyse(ctx, docFromHTML(t, htmlForMicrodata))
+	assert.Len(t, actual.Microdata, 1)
+	item := actual.Microdata[0]
+	assert.Equal(t, "Article", item.Type)
+	assert.Equal(t, "Release notes", item.Properties["headline"])
+	assert.Equal(t, []interface{}{"go", "html"}, item.Properties["keywords"])
+	author := item.Properties["author"].(map[string]interface{})
+	assert.Equal(t, "Jane", author["name"])
+	assert.Equal(t, "Person", author["@type"])
+	// the person name is not a property of the article
+	_, ok := item.Properties["name"]
+	assert.False(t, ok)
+	assert.Contains(t, findingCodes(actual.Findings), "schema_required_missing")
+}
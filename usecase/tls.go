@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/web-page-analysis/domain"
+	"net/url"
+	"time"
+)
+
+const (
+	tlsPrefix = "usecase.tls "
+
+	// certificates expiring within this many days are reported
+	certificateExpiryWarningDays = 30
+)
+
+type TLS interface {
+	Analyse(ctx context.Context, state *tls.ConnectionState, pageURL string) (tlsInfo domain.TLS)
+}
+
+type tlsInspector struct {
+	// roots the chain is verified against, the system roots when nil
+	roots *x509.CertPool
+}
+
+// Analyse reports the certificate chain and the negotiated parameters of the connection
+// the page was served over, the chain is verified here since a page failing the verification
+// is fetched again without it to be able to report the bad certificate
+func (t tlsInspector) Analyse(ctx context.Context, state *tls.ConnectionState, pageURL string) (result domain.TLS) {
+	log.WithContext(ctx).Info(tlsPrefix, "start to inspect the tls connection")
+	result.Certificates = make([]domain.Certificate, 0)
+	result.Findings = make([]domain.Finding, 0)
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return result
+	}
+	result.Enabled = true
+	result.Version = tls.VersionName(state.Version)
+	result.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+
+	now := time.Now()
+	for i, cert := range state.PeerCertificates {
+		certificate := domain.Certificate{
+			Subject:         cert.Subject.String(),
+			Issuer:          cert.Issuer.String(),
+			SANs:            certificateSANs(cert),
+			NotBefore:       cert.NotBefore,
+			NotAfter:        cert.NotAfter,
+			DaysUntilExpiry: int(cert.NotAfter.Sub(now).Hours() / 24),
+			SelfSigned:      isSelfSigned(cert),
+		}
+		result.Certificates = append(result.Certificates, certificate)
+		t.checkValidity(&result, i, cert, certificate, now)
+	}
+
+	leaf := state.PeerCertificates[0]
+	if page, err := url.Parse(pageURL); err == nil {
+		result.HostnameMatch = leaf.VerifyHostname(page.Hostname()) == nil
+	}
+	if !result.HostnameMatch {
+		result.Findings = append(result.Findings, newFinding("tls_hostname_mismatch", domain.SeverityError,
+			"the certificate does not cover the host of the page"))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         t.roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	result.Trusted = err == nil
+	switch {
+	case result.Certificates[0].SelfSigned:
+		result.Findings = append(result.Findings, newFinding("tls_self_signed", domain.SeverityError,
+			"the certificate is self-signed"))
+	case !result.Trusted:
+		result.Findings = append(result.Findings, newFinding("tls_untrusted", domain.SeverityError,
+			fmt.Sprintf("the certificate chain is not trusted: %v", err)))
+	}
+
+	if state.Version < tls.VersionTLS12 {
+		result.Findings = append(result.Findings, newFinding("tls_old_version", domain.SeverityError,
+			fmt.Sprintf("%s is deprecated, TLS 1.2 or newer is expected", result.Version)))
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == state.CipherSuite {
+			result.Findings = append(result.Findings, newFinding("tls_weak_cipher", domain.SeverityWarning,
+				fmt.Sprintf("the cipher suite %s is insecure", result.CipherSuite)))
+		}
+	}
+	return result
+}
+
+// IsCertificateError tells whether the request failed on the certificate of the server,
+// a plain http answer on a tls port is not one, fetching it without verification fails all the same
+func IsCertificateError(err error) bool {
+	var (
+		unknownCA   x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidCert x509.CertificateInvalidError
+		verifyErr   *tls.CertificateVerificationError
+	)
+	return errors.As(err, &unknownCA) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidCert) || errors.As(err, &verifyErr)
+}
+
+// VerificationFinding reports the certificate error the page was fetched in spite of,
+// it may come from a redirect hop the tls section does not cover
+func VerificationFinding(err error) domain.Finding {
+	return newFinding("tls_verification_failed", domain.SeverityError,
+		fmt.Sprintf("the certificate verification failed, the page was fetched without it: %v", err))
+}
+
+func NewTLS() TLS {
+	return &tlsInspector{}
+}
+
+func (t tlsInspector) checkValidity(result *domain.TLS, index int, cert *x509.Certificate,
+	certificate domain.Certificate, now time.Time) {
+	name := "the certificate"
+	if index > 0 {
+		name = fmt.Sprintf("the chain certificate %s", cert.Subject.CommonName)
+	}
+	switch {
+	case now.After(cert.NotAfter):
+		result.Findings = append(result.Findings, newFinding("tls_cert_expired", domain.SeverityError,
+			fmt.Sprintf("%s expired on %s", name, cert.NotAfter.Format(time.DateOnly))))
+	case now.Before(cert.NotBefore):
+		result.Findings = append(result.Findings, newFinding("tls_cert_not_yet_valid", domain.SeverityError,
+			fmt.Sprintf("%s is valid from %s", name, cert.NotBefore.Format(time.DateOnly))))
+	case certificate.DaysUntilExpiry < certificateExpiryWarningDays:
+		result.Findings = append(result.Findings, newFinding("tls_cert_expiring", domain.SeverityWarning,
+			fmt.Sprintf("%s expires in %d days", name, certificate.DaysUntilExpiry)))
+	}
+}
+
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
+
+// isSelfSigned tells whether the certificate is signed by its own key
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	return cert.CheckSignatureFrom(cert) == nil
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/domain"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTestCertificate signs the template with the parent key, or with its own key when parent is nil
+func newTestCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func TestTLSAnalyseSelfSigned(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	ctx := context.Background()
+
+	resp, err := server.Client().Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	actual := NewTLS().Analyse(ctx, resp.TLS, server.URL)
+	assert.True(t, actual.Enabled)
+	assert.Equal(t, "TLS 1.3", actual.Version)
+	assert.NotEmpty(t, actual.CipherSuite)
+	assert.True(t, actual.HostnameMatch)
+	assert.False(t, actual.Trusted)
+	assert.Len(t, actual.Certificates, 1)
+	assert.True(t, actual.Certificates[0].SelfSigned)
+	assert.Contains(t, actual.Certificates[0].SANs, "127.0.0.1")
+	assert.Equal(t, []string{"tls_self_signed"}, findingCodes(actual.Findings))
+}
+
+func TestTLSAnalyseChain(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	ca, caKey := newTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	leaf, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "abc.com"},
+		DNSNames:     []string{"abc.com", "www.abc.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(10*24*time.Hour + time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	state := &tls.ConnectionState{
+		Version:          tls.VersionTLS12,
+		CipherSuite:      tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		PeerCertificates: []*x509.Certificate{leaf},
+	}
+	inspector := tlsInspector{roots: roots}
+
+	actual := inspector.Analyse(ctx, state, "https://www.abc.com/page")
+	assert.True(t, actual.Trusted)
+	assert.True(t, actual.HostnameMatch)
+	assert.Equal(t, "TLS 1.2", actual.Version)
+	assert.Equal(t, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", actual.CipherSuite)
+	assert.Equal(t, 10, actual.Certificates[0].DaysUntilExpiry)
+	assert.Equal(t, "CN=Test Root", actual.Certificates[0].Issuer)
+	assert.False(t, actual.Certificates[0].SelfSigned)
+	assert.Equal(t, []string{"tls_cert_expiring"}, findingCodes(actual.Findings))
+
+	state.Version = tls.VersionTLS10
+	actual = inspector.Analyse(ctx, state, "https://xyz.com/")
+	assert.False(t, actual.HostnameMatch)
+	assert.ElementsMatch(t, []string{"tls_cert_expiring", "tls_hostname_mismatch", "tls_old_version"},
+		findingCodes(actual.Findings))
+
+	// without the root the chain cannot be verified
+	actual = NewTLS().Analyse(ctx, state, "https://abc.com/")
+	assert.False(t, actual.Trusted)
+	assert.Contains(t, findingCodes(actual.Findings), "tls_untrusted")
+}
+
+func TestTLSAnalysePlainHTTP(t *testing.T) {
+	ctx := context.Background()
+
+	actual := NewTLS().Analyse(ctx, nil, "http://abc.com/")
+	assert.False(t, actual.Enabled)
+	assert.Empty(t, actual.Certificates)
+	assert.Empty(t, actual.Findings)
+}
+
+func TestIsCertificateError(t *testing.T) {
+	assert.True(t, IsCertificateError(&url.Error{Op: "Get", Err: x509.UnknownAuthorityError{}}))
+	assert.True(t, IsCertificateError(&tls.CertificateVerificationError{Err: x509.HostnameError{}}))
+	// plain http served on a tls port
+	assert.False(t, IsCertificateError(&url.Error{Op: "Get", Err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}}))
+	assert.Equal(t, domain.LinkErrorConnection, linkErrorCategory(tls.RecordHeaderError{}, 0))
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/domain"
+	"net/url"
+	"strings"
+)
+
+// linkResolver resolves the hrefs of a page the way a browser does
+// and tells whether they point to the page host
+type linkResolver struct {
+	base     *url.URL
+	pageHost string
+	conf     bootstrap.LinkClassificationConfig
+}
+
+// newLinkResolver takes the url the page was fetched from,
+// a <base href> in the document takes over as the base for relative links
+func newLinkResolver(pageURL string, doc *goquery.Document, conf bootstrap.LinkClassificationConfig) (linkResolver, error) {
+	page, err := url.Parse(strings.TrimSpace(pageURL))
+	if err != nil {
+		return linkResolver{}, err
+	}
+	if !page.IsAbs() {
+		return linkResolver{}, errors.New("page url is not absolute: " + pageURL)
+	}
+	resolver := linkResolver{
+		base: page,
+		conf: conf,
+	}
+	resolver.pageHost = resolver.hostKey(page.Hostname())
+
+	if doc != nil {
+		if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+			if base, err := page.Parse(strings.TrimSpace(href)); err == nil {
+				resolver.base = base
+			}
+		}
+	}
+	return resolver, nil
+}
+
+// IsInternalLink tells whether the absolute link points to the host of the page,
+// following the same link_classification rules as the link records
+func IsInternalLink(pageURL string, link string, conf bootstrap.LinkClassificationConfig) bool {
+	resolver, err := newLinkResolver(pageURL, nil, conf)
+	if err != nil {
+		return false
+	}
+	_, linkType, err := resolver.resolve(link)
+	return err == nil && linkType == domain.LinkTypeInternal
+}
+
+// resolve returns the absolute url of the href, without the fragment,
+// and its domain.LinkType
+func (r linkResolver) resolve(href string) (resolved string, linkType string, err error) {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", "", err
+	}
+	link := r.base.ResolveReference(ref)
+	link.Fragment = ""
+	link.RawFragment = ""
+
+	switch strings.ToLower(link.Scheme) {
+	case "http", "https":
+	default:
+		return link.String(), domain.LinkTypeNonHTTP, nil
+	}
+	if link.Path == "" {
+		link.Path = "/"
+	}
+	if r.isInternal(link.Hostname()) {
+		return link.String(), domain.LinkTypeInternal, nil
+	}
+	return link.String(), domain.LinkTypeExternal, nil
+}
+
+// isInternal compares the hosts only, the scheme and the port do not matter
+func (r linkResolver) isInternal(host string) bool {
+	host = r.hostKey(host)
+	if host == r.pageHost {
+		return true
+	}
+	return r.conf.SubdomainsInternal && strings.HasSuffix(host, "."+r.pageHost)
+}
+
+func (r linkResolver) hostKey(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if r.conf.IgnoreWWW {
+		host = strings.TrimPrefix(host, "www.")
+	}
+	return host
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/web-page-analysis/bootstrap"
+	"github.com/web-page-analysis/container"
+	"github.com/web-page-analysis/domain"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestResolveLinks(t *testing.T) {
+	resolver, err := newLinkResolver("https://abc.com/docs/guide/index.html", nil, bootstrap.LinkClassificationConfig{})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		href     string
+		url      string
+		linkType string
+	}{
+		{"../a", "https://abc.com/docs/a", domain.LinkTypeInternal},
+		{"page.html", "https://abc.com/docs/guide/page.html", domain.LinkTypeInternal},
+		{"/about", "https://abc.com/about", domain.LinkTypeInternal},
+		{"?q=1", "https://abc.com/docs/guide/index.html?q=1", domain.LinkTypeInternal},
+		{"//cdn.example.com/x", "https://cdn.example.com/x", domain.LinkTypeExternal},
+		{"http://abc.com/other#top", "http://abc.com/other", domain.LinkTypeInternal},
+		{"https://abc.com:8443", "https://abc.com:8443/", domain.LinkTypeInternal},
+		{"https://ABC.com/upper", "https://ABC.com/upper", domain.LinkTypeInternal},
+		{"https://abcd.com/", "https://abcd.com/", domain.LinkTypeExternal},
+		{"mailto:info@abc.com", "mailto:info@abc.com", domain.LinkTypeNonHTTP},
+		{"tel:+123456", "tel:+123456", domain.LinkTypeNonHTTP},
+		{"javascript:void(0)", "javascript:void(0)", domain.LinkTypeNonHTTP},
+		{"data:text/plain,hi", "data:text/plain,hi", domain.LinkTypeNonHTTP},
+	}
+	for _, c := range cases {
+		resolved, linkType, err := resolver.resolve(c.href)
+		assert.NoError(t, err, c.href)
+		assert.Equal(t, c.url, resolved, c.href)
+		assert.Equal(t, c.linkType, linkType, c.href)
+	}
+}
+
+func TestResolveLinksWithBaseHref(t *testing.T) {
+	var (
+		htmlWithBase = `
+<html>
+<head><base href="https://static.abc.com/assets/"></head>
+<body><a href="img.png">Image</a></body>
+</html>
+`
+	)
+	resolver, err := newLinkResolver("https://abc.com/page", docFromHTML(t, htmlWithBase), bootstrap.LinkClassificationConfig{})
+	assert.NoError(t, err)
+
+	resolved, linkType, err := resolver.resolve("img.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://static.abc.com/assets/img.png", resolved)
+	// the classification is done against the page host, not the base
+	assert.Equal(t, domain.LinkTypeExternal, linkType)
+}
+
+func TestClassifyLinksSubdomainsAndWWW(t *testing.T) {
+	strict, _ := newLinkResolver("https://www.abc.com/", nil, bootstrap.LinkClassificationConfig{})
+	_, linkType, _ := strict.resolve("https://abc.com/a")
+	assert.Equal(t, domain.LinkTypeExternal, linkType)
+
+	relaxed, _ := newLinkResolver("https://www.abc.com/", nil, bootstrap.LinkClassificationConfig{
+		IgnoreWWW:          true,
+		SubdomainsInternal: true,
+	})
+	_, linkType, _ = relaxed.resolve("https://abc.com/a")
+	assert.Equal(t, domain.LinkTypeInternal, linkType)
+	_, linkType, _ = relaxed.resolve("https://blog.abc.com/a")
+	assert.Equal(t, domain.LinkTypeInternal, linkType)
+	_, linkType, _ = relaxed.resolve("https://notabc.com/a")
+	assert.Equal(t, domain.LinkTypeExternal, linkType)
+}
+
+func TestCountLinksNonHTTP(t *testing.T) {
+	var (
+		htmlWithNonHTTP = `
+<html>
+<body>
+    <a href="/about">About</a>
+    <a href="about">About again</a>
+    <a href="mailto:info@abc.com">Mail</a>
+    <a href="javascript:void(0)">Menu</a>
+</body>
+</html>
+`
+	)
+	ctx := context.Background()
+	ctr := container.Container{OBAdapter: mockOutBoundConnection{}}
+	mockOutBoundError = nil
+	mockOutboundResp = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	conf := bootstrap.Config{
+		AppConfig: bootstrap.AppConfig{
+			WorkerCount: 200,
+		},
+	}
+	analyser := NewAnalyser(ctr, conf)
+
+	actual := analyser.CountLinks(ctx, docFromHTML(t, htmlWithNonHTTP), "http://abc.com/")
+	assert.Equal(t, 1, actual.InternalLinks)
+	assert.Equal(t, 2, actual.NonHTTPLinks)
+	assert.Equal(t, 0, actual.InaccessibleLinkCount)
+	assert.Len(t, actual.Details, 3)
+}
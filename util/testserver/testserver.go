@@ -0,0 +1,38 @@
+// Package testserver has the http servers shared by the tests of several packages
+package testserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrencyServer answers every request after a delay and records
+// the most requests it had in flight at once
+type ConcurrencyServer struct {
+	*httptest.Server
+	inFlight    int32
+	maxInFlight int32
+}
+
+func NewConcurrencyServer(delay time.Duration) *ConcurrencyServer {
+	server := &ConcurrencyServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&server.inFlight, 1)
+		defer atomic.AddInt32(&server.inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&server.maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&server.maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(delay)
+	}))
+	return server
+}
+
+// MaxInFlight is the most requests served at the same time so far
+func (s *ConcurrencyServer) MaxInFlight() int32 {
+	return atomic.LoadInt32(&s.maxInFlight)
+}